@@ -14,16 +14,24 @@ import (
     "time"
 )
 
+// DefaultCacheSize is the default limit for the total size of cached artifacts
+const DefaultCacheSize = 512 << 20
+
+// CacheEntry describes a cached build artifact. Artifacts are stored by the
+// hash of their inputs, so an entry never goes stale; it is only evicted
+// when the cache grows beyond its size limit.
 type CacheEntry struct {
-    OutputPath  string    `json:"output_path"`
-    Hash        string    `json:"hash"`
-    Dependencies []string `json:"dependencies"`
-    Timestamp   time.Time `json:"timestamp"`
+    Key        string    `json:"key"`
+    OutputPath string    `json:"output_path"`
+    Size       int64     `json:"size"`
+    Created    time.Time `json:"created"`
+    LastUsed   time.Time `json:"last_used"`
 }
 
 type BuildCache struct {
     cacheDir string
-    entries  map[string]CacheEntry
+    maxBytes int64
+    entries  map[string]CacheEntry // key -> entry
     mu       sync.RWMutex // Add mutex for thread-safe access
 }
 
@@ -41,12 +49,13 @@ func NewBuildCache(cacheDir string) (*BuildCache, error) {
     }
     
     // Create cache directory if it doesn't exist
-    if err := os.MkdirAll(cacheDir, 0755); err != nil {
+    if err := os.MkdirAll(filepath.Join(cacheDir, "artifacts"), 0755); err != nil {
         return nil, fmt.Errorf("failed to create cache directory: %v", err)
     }
     
     cache := &BuildCache{
         cacheDir: cacheDir,
+        maxBytes: DefaultCacheSize,
         entries:  make(map[string]CacheEntry),
     }
     
@@ -56,9 +65,6 @@ func NewBuildCache(cacheDir string) (*BuildCache, error) {
         fmt.Printf("Warning: Could not load cache: %v\n", err)
     }
     
-    // Start periodic cleanup goroutine
-    go cache.periodicCleanup()
-    
     return cache, nil
 }
 
@@ -88,8 +94,12 @@ func (c *BuildCache) load() error {
         return fmt.Errorf("cache file corrupted: %v", err)
     }
     
-    // Clean up any stale entries on load
-    c.cleanupStaleEntries()
+    // Drop entries whose artifact was removed behind our back
+    for key, entry := range c.entries {
+        if !fileExists(entry.OutputPath) {
+            delete(c.entries, key)
+        }
+    }
     
     return nil
 }
@@ -126,35 +136,80 @@ func (c *BuildCache) save() error {
     return nil
 }
 
-// Set adds or updates a cache entry
-func (c *BuildCache) Set(mainFile string, entry CacheEntry) error {
+// Flush writes the cache index to disk, e.g. before the server exits
+func (c *BuildCache) Flush() error {
+    return c.save()
+}
+
+// Put stores the artifact at path under key and evicts least recently used
+// entries if the cache exceeds its size limit
+func (c *BuildCache) Put(key, path string) (CacheEntry, error) {
+    artifact := filepath.Join(c.cacheDir, "artifacts", key+filepath.Ext(path))
+    if err := linkOrCopy(path, artifact); err != nil {
+        return CacheEntry{}, fmt.Errorf("failed to store artifact: %v", err)
+    }
+    
+    info, err := os.Stat(artifact)
+    if err != nil {
+        return CacheEntry{}, fmt.Errorf("failed to stat artifact: %v", err)
+    }
+    
+    now := time.Now()
+    entry := CacheEntry{
+        Key:        key,
+        OutputPath: artifact,
+        Size:       info.Size(),
+        Created:    now,
+        LastUsed:   now,
+    }
+    
     c.mu.Lock()
-    c.entries[mainFile] = entry
+    c.entries[key] = entry
+    c.evict(key)
     c.mu.Unlock()
     
     // Save to disk (could be done async in production)
     if err := c.save(); err != nil {
-        return fmt.Errorf("failed to save cache: %v", err)
+        return entry, fmt.Errorf("failed to save cache: %v", err)
     }
     
-    return nil
+    return entry, nil
 }
 
-// Get retrieves a cache entry
-func (c *BuildCache) Get(mainFile string) (CacheEntry, bool) {
-    c.mu.RLock()
-    defer c.mu.RUnlock()
+// Get retrieves a cache entry and marks it as recently used
+func (c *BuildCache) Get(key string) (CacheEntry, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
     
-    entry, exists := c.entries[mainFile]
-    return entry, exists
+    entry, exists := c.entries[key]
+    if !exists {
+        return CacheEntry{}, false
+    }
+    if !fileExists(entry.OutputPath) {
+        delete(c.entries, key)
+        return CacheEntry{}, false
+    }
+    
+    entry.LastUsed = time.Now()
+    c.entries[key] = entry
+    return entry, true
 }
 
-// Clear removes all cache entries
+// Clear removes all cache entries and their artifacts
 func (c *BuildCache) Clear() error {
     c.mu.Lock()
     c.entries = make(map[string]CacheEntry)
     c.mu.Unlock()
     
+    // Remove artifacts
+    artifacts := filepath.Join(c.cacheDir, "artifacts")
+    if err := os.RemoveAll(artifacts); err != nil {
+        return err
+    }
+    if err := os.MkdirAll(artifacts, 0755); err != nil {
+        return err
+    }
+    
     // Remove cache file
     cacheFile := filepath.Join(c.cacheDir, "cache.json")
     if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
@@ -165,40 +220,50 @@ func (c *BuildCache) Clear() error {
 }
 
 // ClearEntry removes a specific cache entry
-func (c *BuildCache) ClearEntry(mainFile string) {
+func (c *BuildCache) ClearEntry(key string) {
     c.mu.Lock()
-    delete(c.entries, mainFile)
+    if entry, exists := c.entries[key]; exists {
+        os.Remove(entry.OutputPath)
+        delete(c.entries, key)
+    }
     c.mu.Unlock()
     
     // Save asynchronously
     go c.save()
 }
 
-// cleanupStaleEntries removes entries older than the cache duration
-func (c *BuildCache) cleanupStaleEntries() {
-    cutoff := time.Now().Add(-5 * time.Minute) // Same as IsValid check
-    
-    for key, entry := range c.entries {
-        if entry.Timestamp.Before(cutoff) || !fileExists(entry.OutputPath) {
-            delete(c.entries, key)
-        }
-    }
+// SetMaxBytes sets the size limit for cached artifacts
+func (c *BuildCache) SetMaxBytes(maxBytes int64) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.maxBytes = maxBytes
+    c.evict("")
 }
 
-// periodicCleanup runs periodic cleanup of stale entries
-func (c *BuildCache) periodicCleanup() {
-    ticker := time.NewTicker(15 * time.Minute)
-    defer ticker.Stop()
-    
-    for range ticker.C {
-        c.mu.Lock()
-        c.cleanupStaleEntries()
-        c.mu.Unlock()
-        
-        // Save after cleanup
-        if err := c.save(); err != nil {
-            fmt.Printf("Warning: Failed to save cache during cleanup: %v\n", err)
+// evict removes least recently used entries until the cache fits its size
+// limit. The entry for keep is never evicted. Callers must hold c.mu.
+func (c *BuildCache) evict(keep string) {
+    var total int64
+    entries := make([]CacheEntry, 0, len(c.entries))
+    for _, entry := range c.entries {
+        total += entry.Size
+        entries = append(entries, entry)
+    }
+    
+    sort.Slice(entries, func(i, j int) bool {
+        return entries[i].LastUsed.Before(entries[j].LastUsed)
+    })
+    
+    for _, entry := range entries {
+        if total <= c.maxBytes {
+            break
+        }
+        if entry.Key == keep {
+            continue
         }
+        os.Remove(entry.OutputPath)
+        delete(c.entries, entry.Key)
+        total -= entry.Size
     }
 }
 
@@ -214,17 +279,16 @@ func (c *BuildCache) GetEntryCount() int {
     return len(c.entries)
 }
 
-func (c *BuildCache) IsValid(mainFile string, changedFiles []string) bool {
-    entry, exists := c.entries[mainFile]
-    if !exists {
-        return false
-    }
+// Size returns the total size of cached artifacts in bytes
+func (c *BuildCache) Size() int64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
     
-    // Check if any dependency changed
-    currentHash := c.calculateHash(mainFile, changedFiles)
-    return entry.Hash == currentHash && 
-           time.Since(entry.Timestamp) < 5*time.Minute &&
-           fileExists(entry.OutputPath)
+    var total int64
+    for _, entry := range c.entries {
+        total += entry.Size
+    }
+    return total
 }
 
 // fileExists is a helper function
@@ -233,40 +297,31 @@ func fileExists(path string) bool {
     return err == nil
 }
 
-// calculateHash computes a unique hash for the build based on:
-// 1. The content of the main file
-// 2. The content of all changed files (dependencies)
-// 3. The list of dependencies themselves
-func (c *BuildCache) calculateHash(mainFile string, changedFiles []string) string {
+// HashInputs computes the cache key for a build from:
+// 1. The path and content of every input file
+// 2. Extra build parameters (tags, ldflags, toolchain version, ...)
+func HashInputs(files []string, extra ...string) (string, error) {
     hasher := sha256.New()
     
-    // 1. Add main file content to hash
-    if err := addFileContentToHash(hasher, mainFile); err != nil {
-        // If we can't read the main file, return empty hash
-        return ""
-    }
-    
-    // 2. Add all changed files content to hash
     // Sort to ensure consistent hash regardless of order
-    sort.Strings(changedFiles)
+    sorted := append([]string{}, files...)
+    sort.Strings(sorted)
+    
+    for _, file := range sorted {
+        // Add the filename itself (moving a file changes the build)
+        fmt.Fprintf(hasher, "%s\x00", file)
     
-    for _, file := range changedFiles {
-        // Add the filename itself (dependency relationship matters)
-        hasher.Write([]byte(file))
-        
-        // Add the file content
         if err := addFileContentToHash(hasher, file); err != nil {
-            // If a dependency is missing, we should rebuild
-            continue
+            return "", err
         }
     }
     
-    // 3. Add current timestamp (optional, for freshness)
-    // This ensures builds aren't cached indefinitely
-    hasher.Write([]byte(time.Now().Format("2006-01-02-15"))) // Hourly granularity
+    for _, e := range extra {
+        fmt.Fprintf(hasher, "%s\x00", e)
+    }
     
     // Return hex-encoded hash
-    return hex.EncodeToString(hasher.Sum(nil))
+    return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // addFileContentToHash reads a file and adds its content to the hash
@@ -294,3 +349,33 @@ func addFileContentToHash(hasher io.Writer, filePath string) error {
     
     return nil
 }
+
+// linkOrCopy hard-links src to dst, falling back to a copy across devices
+func linkOrCopy(src, dst string) error {
+    os.Remove(dst)
+    if err := os.Link(src, dst); err == nil {
+        return nil
+    }
+    
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+    
+    tmp := dst + ".tmp"
+    out, err := os.Create(tmp)
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(out, in); err != nil {
+        out.Close()
+        os.Remove(tmp)
+        return err
+    }
+    if err := out.Close(); err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    return os.Rename(tmp, dst)
+}
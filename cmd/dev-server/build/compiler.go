@@ -10,43 +10,140 @@ import (
     "os/exec"
     "path/filepath"
     "strings"
+    "sync"
     "time"
 )
 
 type Compiler struct {
+    target        Target
     goBinary      string
     workDir       string
     outputDir     string
+    mainPackage   string
     cache         *BuildCache
     buildTags     []string
     ldflags       string
+    graph         *ImportGraph
+    graphMu       sync.Mutex
+    stats         BuildStats
+    statsMu       sync.RWMutex
+    goVersion     string
+    wasmExec      string // resolved runtime shim, see WasmExecPath
+    wasmExecMu    sync.Mutex
+    profiling     bool
+    profiles      []BuildProfile
+    profileMu     sync.Mutex
 }
 
-func NewCompiler(workDir, outputDir string) (*Compiler, error) {
+// BuildStats summarizes compiler activity for the dashboard
+type BuildStats struct {
+    Builds       int           // builds that ran the go tool
+    CacheHits    int           // builds answered from the cache
+    LastDuration time.Duration // duration of the last go build
+    LastCacheHit bool          // whether the last request was a cache hit
+    LastOutput   string        // artifact produced or reused by the last request
+}
+
+// NewCompiler returns a compiler for target. Relative paths of the target
+// are resolved against workDir, the module root.
+func NewCompiler(workDir string, target Target) (*Compiler, error) {
+    outputDir := target.OutputDir(workDir)
+    if err := os.MkdirAll(outputDir, 0755); err != nil {
+        return nil, fmt.Errorf("failed to create output directory: %v", err)
+    }
+    
     cache, err := NewBuildCache(filepath.Join(outputDir, ".buildcache"))
     if err != nil {
         return nil, fmt.Errorf("failed to create build cache: %v", err)
     }
     
+    // Absolute paths are needed to match watcher events against the
+    // package directories reported by go list
+    absWorkDir, err := filepath.Abs(workDir)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get absolute path: %v", err)
+    }
+    
+    goBinary := target.GoBinary
+    if goBinary == "" {
+        goBinary = "go"
+    }
+    
     return &Compiler{
-        goBinary:  "go",
-        workDir:   workDir,
-        outputDir: outputDir,
-        cache:     cache,
-        buildTags: []string{"dev"},
-        ldflags:   "-s -w",
+        target:      target,
+        goBinary:    goBinary,
+        workDir:     absWorkDir,
+        outputDir:   outputDir,
+        mainPackage: target.Package,
+        cache:       cache,
+        buildTags:   target.Tags,
+        ldflags:     target.Ldflags,
     }, nil
 }
 
+// Target returns the target the compiler builds
+func (c *Compiler) Target() Target {
+    return c.target
+}
+
+// OutputDir returns the directory app-*.wasm artifacts are written to
+func (c *Compiler) OutputDir() string {
+    return c.outputDir
+}
+
+// BuildWasm builds mainFile to a new app-*.wasm artifact in the output
+// directory, reusing a cached artifact when the inputs are unchanged
 func (c *Compiler) BuildWasm(ctx context.Context, mainFile string, changedFiles []string) (string, error) {
+    return c.build(ctx, mainFile, changedFiles, true)
+}
+
+// Build builds the wasm entry point, reusing a cached artifact when the
+// inputs are unchanged
+func (c *Compiler) Build(ctx context.Context) (string, error) {
+    c.invalidateGraph()
+    return c.build(ctx, c.mainFile(), nil, true)
+}
+
+// Rebuild builds the wasm entry point, bypassing the cache
+func (c *Compiler) Rebuild(ctx context.Context) (string, error) {
+    c.invalidateGraph()
+    return c.build(ctx, c.mainFile(), nil, false)
+}
+
+func (c *Compiler) build(ctx context.Context, mainFile string, changedFiles []string, useCache bool) (string, error) {
+    times := buildTimes{start: time.Now()}
+    
     // Check cache
-    if c.cache.IsValid(mainFile, changedFiles) {
-        if entry, exists := c.cache.Get(mainFile); exists {
-            return entry.OutputPath, nil
+    key, err := c.cacheKey(ctx)
+    times.keyDone = time.Now()
+    if ctx.Err() != nil {
+        return "", ctx.Err()
+    }
+    if err != nil {
+        log.Printf("Warning: cannot compute cache key, building uncached: %v", err)
+    }
+    outputPath := filepath.Join(c.outputDir, fmt.Sprintf("app-%d.wasm", time.Now().UnixNano()))
+    
+    if useCache && key != "" {
+        if entry, exists := c.cache.Get(key); exists {
+            // Serve a copy in the output directory like any other build;
+            // the artifact itself goes away when the cache is cleared or
+            // evicts it
+            err := linkOrCopy(entry.OutputPath, outputPath)
+            if err == nil {
+                c.recordBuild(0, true, outputPath)
+                times.done = time.Now()
+                c.recordProfile(times, true, outputPath)
+                return outputPath, nil
+            }
+            log.Printf("Warning: cannot use cached artifact, building: %v", err)
         }
     }
     
-    outputPath := filepath.Join(c.outputDir, fmt.Sprintf("app-%d.wasm", time.Now().UnixNano()))
+    // go build writes to a temporary file that is renamed into place on
+    // success, so a canceled or failed build never leaves a partial binary
+    tmpPath := outputPath + ".tmp"
+    defer os.Remove(tmpPath)
     
     // Use the WORKDIR as the module root
     absMainFile, err := filepath.Abs(mainFile)
@@ -55,14 +152,22 @@ func (c *Compiler) BuildWasm(ctx context.Context, mainFile string, changedFiles
     }
     
     // Build with explicit module mode
-    cmd := exec.CommandContext(ctx, c.goBinary, "build",
-        "-o", outputPath,
-        "-tags", joinTags(c.buildTags),
-        "-ldflags", c.ldflags,
-    )
+    cmd := exec.CommandContext(ctx, c.goBinary, c.buildArgs(tmpPath)...)
+    
+    // In profiling mode go build reports when each action ran (TinyGo
+    // has no equivalent)
+    if c.Profiling() && !c.target.TinyGo() {
+        if f, err := os.CreateTemp("", "actiongraph-*.json"); err == nil {
+            f.Close()
+            times.graphFile = f.Name()
+            defer os.Remove(times.graphFile)
+            cmd.Args = append(cmd.Args, "-debug-actiongraph="+times.graphFile)
+        }
+    }
     
-    // For local packages, use the directory containing go.mod
-    cmd.Args = append(cmd.Args, "./"+filepath.ToSlash(strings.TrimPrefix(absMainFile, c.workDir+string(filepath.Separator))))
+    // Build the package rather than main.go alone, which would leave out
+    // the package's other files. Local packages are relative to go.mod.
+    cmd.Args = append(cmd.Args, "./"+filepath.ToSlash(strings.TrimPrefix(filepath.Dir(absMainFile), c.workDir+string(filepath.Separator))))
     
     cmd.Dir = c.workDir  // This is CRITICAL - must be the module root
     
@@ -92,63 +197,209 @@ func (c *Compiler) BuildWasm(ctx context.Context, mainFile string, changedFiles
     cmd.Stderr = &stderr
     
     start := time.Now()
+    times.cmdStart = start
     err = cmd.Run()
     buildTime := time.Since(start)
+    times.cmdDone = time.Now()
     
     if err != nil {
-        // Try one more approach - build from current directory
-        return c.buildFromCurrentDir(mainFile, outputPath)
+        if ctx.Err() != nil {
+            return "", ctx.Err()
+        }
+        
+        return "", &BuildError{
+            Output:      stderr.String(),
+            Diagnostics: ParseDiagnostics(stderr.String(), c.workDir),
+        }
     }
     
-    // Update cache
-    entry := CacheEntry{
-        OutputPath:   outputPath,
-        Hash:         c.cache.calculateHash(mainFile, changedFiles),
-        Dependencies: changedFiles,
-        Timestamp:    time.Now(),
+    if err := os.Rename(tmpPath, outputPath); err != nil {
+        return "", fmt.Errorf("failed to move build output into place: %v", err)
     }
+    c.recordBuild(buildTime, false, outputPath)
     
-    if err := c.cache.Set(mainFile, entry); err != nil {
-        log.Printf("Warning: Failed to update cache: %v", err)
-    }
+    // Store the artifact under its content key
+    c.store(ctx, key, outputPath)
+    
+    times.done = time.Now()
+    c.recordProfile(times, false, outputPath)
     
     log.Printf("Built %s in %v", filepath.Base(outputPath), buildTime)
     return outputPath, nil
 }
 
-// BuildOnlyChanged implements incremental compilation
+// buildArgs returns the arguments of the build command writing to
+// outputPath, without the package. TinyGo has no linker flags; -s and -w
+// map to dropping debug information.
+func (c *Compiler) buildArgs(outputPath string) []string {
+    if !c.target.TinyGo() {
+        return []string{"build",
+            "-o", outputPath,
+            "-tags", joinTags(c.buildTags),
+            "-ldflags", c.ldflags,
+        }
+    }
+    
+    args := []string{"build",
+        "-o", outputPath,
+        "-target", "wasm",
+        "-tags", joinTags(c.buildTags),
+    }
+    for _, flag := range strings.Fields(c.ldflags) {
+        if flag == "-s" || flag == "-w" {
+            args = append(args, "-no-debug")
+            break
+        }
+    }
+    return args
+}
+
+// BuildOnlyChanged rebuilds the wasm entry point only if one of the changed
+// files is part of its dependency closure. It returns "" without building
+// when nothing reachable from the entry point changed (e.g. edits under
+// cmd/dev-server). The go tool's own build cache takes care of recompiling
+// only the affected packages.
 func (c *Compiler) BuildOnlyChanged(ctx context.Context, changedFiles []string) (string, error) {
-    // Analyze which packages are affected
-    affectedPackages := c.analyzeDependencies(changedFiles)
+    affectedPackages, err := c.analyzeDependencies(ctx, changedFiles)
+    if err != nil {
+        return "", fmt.Errorf("failed to analyze dependencies: %v", err)
+    }
     
     if len(affectedPackages) == 0 {
         // No relevant changes
         return "", nil
     }
     
-    // Create a temporary main.go that imports only affected packages
-    tempMain, err := c.createSelectiveMain(affectedPackages)
+    // Imports may have changed; reload the graph before the next analysis
+    c.invalidateGraph()
+    
+    return c.BuildWasm(ctx, c.mainFile(), changedFiles)
+}
+
+// mainFile returns the path of the wasm entry point's main.go
+func (c *Compiler) mainFile() string {
+    return filepath.Join(c.workDir, filepath.FromSlash(c.mainPackage), "main.go")
+}
+
+// recordBuild updates the build statistics
+func (c *Compiler) recordBuild(duration time.Duration, cacheHit bool, outputPath string) {
+    c.statsMu.Lock()
+    defer c.statsMu.Unlock()
+    
+    if cacheHit {
+        c.stats.CacheHits++
+    } else {
+        c.stats.Builds++
+        c.stats.LastDuration = duration
+    }
+    c.stats.LastCacheHit = cacheHit
+    c.stats.LastOutput = outputPath
+}
+
+// Stats returns a snapshot of the build statistics
+func (c *Compiler) Stats() BuildStats {
+    c.statsMu.RLock()
+    defer c.statsMu.RUnlock()
+    return c.stats
+}
+
+// Cache returns the compiler's build cache
+func (c *Compiler) Cache() *BuildCache {
+    return c.cache
+}
+
+// store caches the artifact at outputPath under key, the cache key computed
+// before the build. Sources edited while go build ran may or may not be in
+// the binary, so the key is computed again and the artifact is only stored
+// if the inputs did not change.
+func (c *Compiler) store(ctx context.Context, key, outputPath string) {
+    if key == "" {
+        return
+    }
+    
+    // Files may also have been added or removed
+    c.invalidateGraph()
+    after, err := c.cacheKey(ctx)
+    if err != nil || after != key {
+        log.Printf("Sources changed during the build, not caching %s", filepath.Base(outputPath))
+        return
+    }
+    
+    if _, err := c.cache.Put(key, outputPath); err != nil {
+        log.Printf("Warning: Failed to update cache: %v", err)
+    }
+}
+
+// cacheKey derives the cache key of the wasm build from every source file
+// in the module that the entry point depends on, go.mod/go.sum (which pin
+// all external dependencies), the build flags and the toolchain version
+func (c *Compiler) cacheKey(ctx context.Context) (string, error) {
+    if c.goVersion == "" {
+        version, err := c.GetGoVersion()
+        if err != nil {
+            return "", err
+        }
+        c.goVersion = version
+    }
+    
+    graph, err := c.buildImportGraph(ctx)
     if err != nil {
-        return "", fmt.Errorf("failed to create selective main: %v", err)
+        return "", err
     }
-    defer os.Remove(tempMain)
     
-    return c.BuildWasm(ctx, tempMain, changedFiles)
+    var files []string
+    for _, name := range []string{"go.mod", "go.sum"} {
+        if path := filepath.Join(c.workDir, name); fileExists(path) {
+            files = append(files, path)
+        }
+    }
+    for _, pkg := range graph.Packages {
+        // Packages outside the module are covered by go.sum
+        if !strings.HasPrefix(pkg.Dir, c.workDir+string(filepath.Separator)) && pkg.Dir != c.workDir {
+            continue
+        }
+        for _, name := range pkg.GoFiles {
+            files = append(files, filepath.Join(pkg.Dir, name))
+        }
+        for _, name := range pkg.EmbedFiles {
+            files = append(files, filepath.Join(pkg.Dir, name))
+        }
+    }
+    
+    return HashInputs(files,
+        "compiler="+c.goBinary,
+        "main="+c.mainPackage,
+        "tags="+joinTags(c.buildTags),
+        "ldflags="+c.ldflags,
+        "toolchain="+c.goVersion,
+        "GOOS=js", "GOARCH=wasm",
+    )
 }
 
-func (c *Compiler) analyzeDependencies(changedFiles []string) []string {
-    // Parse Go files to build import graph
-    importGraph := c.buildImportGraph()
+// analyzeDependencies returns the packages affected by changedFiles: the
+// packages that own them plus everything that imports those, transitively
+func (c *Compiler) analyzeDependencies(ctx context.Context, changedFiles []string) ([]string, error) {
+    importGraph, err := c.buildImportGraph(ctx)
+    if err != nil {
+        return nil, err
+    }
     
     affectedPackages := make(map[string]bool)
     for _, changedFile := range changedFiles {
-        pkg := c.fileToPackage(changedFile)
+        // Module files can change any package's dependencies
+        switch filepath.Base(changedFile) {
+        case "go.mod", "go.sum", "go.work", "go.work.sum":
+            c.findDependents(importGraph.Main, importGraph.Dependents, affectedPackages)
+            continue
+        }
+        
+        pkg := c.fileToPackage(importGraph, changedFile)
         if pkg == "" {
             continue
         }
         
         // Find all packages that depend on this package
-        c.findDependents(pkg, importGraph, affectedPackages)
+        c.findDependents(pkg, importGraph.Dependents, affectedPackages)
     }
     
     // Convert to slice
@@ -157,7 +408,7 @@ func (c *Compiler) analyzeDependencies(changedFiles []string) []string {
         result = append(result, pkg)
     }
     
-    return result
+    return result, nil
 }
 
 // joinTags joins build tags with commas
@@ -165,34 +416,66 @@ func joinTags(tags []string) string {
     return strings.Join(tags, ",")
 }
 
-// buildImportGraph builds a map of package -> packages that import it
-func (c *Compiler) buildImportGraph() map[string][]string {
-    // This is a simplified implementation
-    // In a real implementation, you would parse .go files to find imports
+// buildImportGraph returns the package graph of the wasm entry point,
+// loading it with go list on first use or after invalidateGraph
+func (c *Compiler) buildImportGraph(ctx context.Context) (*ImportGraph, error) {
+    c.graphMu.Lock()
+    defer c.graphMu.Unlock()
+    
+    if c.graph != nil {
+        return c.graph, nil
+    }
     
-    // For now, return an empty graph
-    return make(map[string][]string)
-}
-
-// fileToPackage converts a file path to its package name
-func (c *Compiler) fileToPackage(filePath string) string {
-    relPath, err := filepath.Rel(c.workDir, filePath)
+    graph, err := LoadImportGraph(ctx, c.listBinary(), c.workDir, c.mainPackage, c.listTags(), c.wasmEnv())
     if err != nil {
-        return ""
+        return nil, err
     }
     
-    // Get the directory containing the file
-    dir := filepath.Dir(relPath)
-    if dir == "." {
-        dir = ""
+    c.graph = graph
+    return graph, nil
+}
+
+// listBinary returns the go command used to load the package graph.
+// TinyGo is a drop-in for go build but not for go list, so the Go
+// toolchain lists TinyGo targets too.
+func (c *Compiler) listBinary() string {
+    if c.target.TinyGo() {
+        return "go"
     }
-    
-    // In Go, package name is usually the directory name
-    // This is simplified - real implementation would parse the package declaration
-    if dir == "" {
-        return "main"
+    return c.goBinary
+}
+
+// listTags returns the build tags the package graph is loaded with,
+// including the tag TinyGo sets itself
+func (c *Compiler) listTags() []string {
+    if c.target.TinyGo() {
+        return append(append([]string{}, c.buildTags...), "tinygo")
     }
-    return dir
+    return c.buildTags
+}
+
+// invalidateGraph forces the next analysis to reload the package graph
+func (c *Compiler) invalidateGraph() {
+    c.graphMu.Lock()
+    c.graph = nil
+    c.graphMu.Unlock()
+}
+
+// fileToPackage converts a file path to the import path of the package
+// that contains it, or "" if the file is not reachable from the entry point
+func (c *Compiler) fileToPackage(importGraph *ImportGraph, filePath string) string {
+    return importGraph.PackageOf(filePath)
+}
+
+// wasmEnv returns the environment for go commands targeting js/wasm
+func (c *Compiler) wasmEnv() []string {
+    var env []string
+    for _, e := range os.Environ() {
+        if !strings.HasPrefix(e, "GOOS=") && !strings.HasPrefix(e, "GOARCH=") {
+            env = append(env, e)
+        }
+    }
+    return append(env, "GOOS=js", "GOARCH=wasm")
 }
 
 // findDependents recursively finds all packages that depend on the given package
@@ -210,49 +493,11 @@ func (c *Compiler) findDependents(pkg string, importGraph map[string][]string, v
     }
 }
 
-// createSelectiveMain creates a temporary main.go file that imports only affected packages
-func (c *Compiler) createSelectiveMain(packages []string) (string, error) {
-    // Create temporary directory for the main file
-    tempDir, err := os.MkdirTemp("", "selective-build-*")
-    if err != nil {
-        return "", fmt.Errorf("failed to create temp dir: %v", err)
-    }
-    
-    mainFile := filepath.Join(tempDir, "main.go")
-    
-    // Create the main.go content
-    var content bytes.Buffer
-    content.WriteString("// +build dev\n\n")
-    content.WriteString("package main\n\n")
-    content.WriteString("import (\n")
-    
-    for _, pkg := range packages {
-        // Determine import path
-        importPath := pkg
-        if !strings.Contains(pkg, "/") {
-            // Local package
-            importPath = fmt.Sprintf("./%s", pkg)
-        }
-        fmt.Fprintf(&content, "    _ \"%s\"\n", importPath)
-    }
-    
-    content.WriteString(")\n\n")
-    content.WriteString("func main() {\n")
-    content.WriteString("    // This main is only for selective compilation\n")
-    content.WriteString("    // Real entry point is in the actual application\n")
-    content.WriteString("}\n")
-    
-    // Write the file
-    if err := os.WriteFile(mainFile, content.Bytes(), 0644); err != nil {
-        os.RemoveAll(tempDir)
-        return "", fmt.Errorf("failed to write main.go: %v", err)
-    }
-    
-    return mainFile, nil
-}
-
-// Cleanup removes old build artifacts
+// Cleanup removes app-*.wasm artifacts older than maxAge. The artifact of
+// the last build is kept, since it is the one being served.
 func (c *Compiler) Cleanup(maxAge time.Duration) error {
+    current := filepath.Base(c.Stats().LastOutput)
+    
     entries, err := os.ReadDir(c.outputDir)
     if err != nil {
         return fmt.Errorf("failed to read output directory: %v", err)
@@ -269,7 +514,12 @@ func (c *Compiler) Cleanup(maxAge time.Duration) error {
             continue
         }
         
-        if info.ModTime().Before(cutoff) && strings.HasSuffix(info.Name(), ".wasm") {
+        name := info.Name()
+        if name == current || !strings.HasPrefix(name, "app-") || !strings.HasSuffix(name, ".wasm") {
+            continue
+        }
+        
+        if info.ModTime().Before(cutoff) {
             filePath := filepath.Join(c.outputDir, info.Name())
             if err := os.Remove(filePath); err != nil {
                 log.Printf("Failed to remove old build %s: %v", info.Name(), err)
@@ -291,88 +541,3 @@ func (c *Compiler) GetGoVersion() (string, error) {
     }
     return strings.TrimSpace(string(output)), nil
 }
-
-// BuildWasmToPath builds WebAssembly to a specific output path
-func (c *Compiler) BuildWasmToPath(ctx context.Context, mainFile string, changedFiles []string, outputPath string) (string, error) {
-    // Check cache for unchanged files
-    if c.cache.IsValid(mainFile, changedFiles) {
-        if entry, exists := c.cache.Get(mainFile); exists {
-            return entry.OutputPath, nil
-        }
-    }
-    
-    // Build command
-    cmd := exec.CommandContext(ctx, c.goBinary, "build",
-        "-o", outputPath,
-        "-tags", joinTags(c.buildTags),
-        "-ldflags", c.ldflags,
-        mainFile,
-    )
-    
-    cmd.Dir = c.workDir
-    cmd.Env = append(os.Environ(),
-        "GOOS=js",
-        "GOARCH=wasm",
-        "GO111MODULE=on",
-    )
-    
-    var stdout, stderr bytes.Buffer
-    cmd.Stdout = &stdout
-    cmd.Stderr = &stderr
-    
-    start := time.Now()
-    err := cmd.Run()
-    buildTime := time.Since(start)
-    
-    if err != nil {
-        log.Printf("Build failed with error: %v", err)
-        log.Printf("Stderr output: %s", stderr.String())
-        return "", fmt.Errorf("build failed: %v\n%s", err, stderr.String())
-    }
-    
-    // Update cache
-    entry := CacheEntry{
-        OutputPath:   outputPath,
-        Hash:         c.cache.calculateHash(mainFile, changedFiles),
-        Dependencies: changedFiles,
-        Timestamp:    time.Now(),
-    }
-    
-    if err := c.cache.Set(mainFile, entry); err != nil {
-        log.Printf("Warning: Failed to update cache: %v", err)
-    }
-    
-    log.Printf("Built %s in %v", filepath.Base(outputPath), buildTime)
-    return outputPath, nil
-}
-
-func (c *Compiler) buildFromCurrentDir(mainFile, outputPath string) (string, error) {
-    // Alternative: build using the current directory approach
-    relPath, err := filepath.Rel(c.workDir, mainFile)
-    if err != nil {
-        return "", fmt.Errorf("failed to get relative path: %v", err)
-    }
-    
-    cmd := exec.Command("go", "build",
-        "-o", outputPath,
-        "-tags", joinTags(c.buildTags),
-        "-ldflags", c.ldflags,
-        "./"+filepath.ToSlash(relPath),
-    )
-    
-    cmd.Dir = c.workDir
-    cmd.Env = append(os.Environ(),
-        "GOOS=js",
-        "GOARCH=wasm",
-        "GO111MODULE=on",
-    )
-    
-    var stderr bytes.Buffer
-    cmd.Stderr = &stderr
-    
-    if err := cmd.Run(); err != nil {
-        return "", fmt.Errorf("fallback build failed: %v\n%s", err, stderr.String())
-    }
-    
-    return outputPath, nil
-}
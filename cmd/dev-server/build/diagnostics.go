@@ -0,0 +1,78 @@
+// cmd/dev-server/build/diagnostics.go
+package build
+
+import (
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// Diagnostic is a single compiler error with its source position
+type Diagnostic struct {
+    File    string `json:"file"`
+    Line    int    `json:"line"`
+    Column  int    `json:"column,omitempty"`
+    Message string `json:"message"`
+    Package string `json:"package,omitempty"`
+    Link    string `json:"link,omitempty"` // editor URL, filled in by the server
+}
+
+// BuildError is returned when go build fails. It carries the raw output as
+// well as the diagnostics parsed from it.
+type BuildError struct {
+    Output      string
+    Diagnostics []Diagnostic
+}
+
+func (e *BuildError) Error() string {
+    return fmt.Sprintf("build failed:\n%s", e.Output)
+}
+
+// file.go:12:5: message  or  file.go:12: message
+var diagnosticLine = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// ParseDiagnostics extracts file/line/column diagnostics from go build
+// output. Relative paths are resolved against workDir, which is where the
+// go command ran.
+func ParseDiagnostics(output, workDir string) []Diagnostic {
+    var diagnostics []Diagnostic
+    var pkg string
+
+    for _, line := range strings.Split(output, "\n") {
+        switch {
+        case strings.HasPrefix(line, "# "):
+            // "# import/path" introduces the errors of one package
+            pkg = strings.TrimPrefix(line, "# ")
+
+        case strings.HasPrefix(line, "\t") && len(diagnostics) > 0:
+            // Indented lines continue the previous message
+            last := &diagnostics[len(diagnostics)-1]
+            last.Message += "\n" + strings.TrimSpace(line)
+
+        default:
+            m := diagnosticLine.FindStringSubmatch(line)
+            if m == nil {
+                continue
+            }
+
+            file := m[1]
+            if !filepath.IsAbs(file) {
+                file = filepath.Join(workDir, file)
+            }
+            lineNo, _ := strconv.Atoi(m[2])
+            column, _ := strconv.Atoi(m[3])
+
+            diagnostics = append(diagnostics, Diagnostic{
+                File:    file,
+                Line:    lineNo,
+                Column:  column,
+                Message: m[4],
+                Package: pkg,
+            })
+        }
+    }
+
+    return diagnostics
+}
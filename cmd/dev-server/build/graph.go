@@ -0,0 +1,148 @@
+// cmd/dev-server/build/graph.go
+package build
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os/exec"
+    "path/filepath"
+    "strings"
+)
+
+// Package is the subset of `go list -json` output the compiler needs
+type Package struct {
+    ImportPath     string
+    Dir            string
+    GoFiles        []string
+    IgnoredGoFiles []string
+    EmbedFiles     []string
+    Imports        []string
+    Standard       bool
+}
+
+// ImportGraph is the package graph of a wasm entry point, as seen by the
+// compiler with the same GOOS/GOARCH and build tags as the real build
+type ImportGraph struct {
+    Main       string              // import path of the entry point
+    Packages   map[string]*Package // import path -> package
+    Dependents map[string][]string // import path -> packages that import it
+    dirs       map[string]string   // absolute directory -> import path
+}
+
+// LoadImportGraph runs `go list -deps -json` for mainPackage and builds the
+// reverse dependency graph. Standard library packages are left out since
+// edits in the working tree cannot affect them.
+func LoadImportGraph(ctx context.Context, goBinary, workDir, mainPackage string, tags []string, env []string) (*ImportGraph, error) {
+    // -e keeps going on packages with errors, so a syntax error in one
+    // file still yields a usable graph
+    cmd := exec.CommandContext(ctx, goBinary, "list", "-e", "-deps", "-json",
+        "-tags", joinTags(tags),
+        mainPackage,
+    )
+    cmd.Dir = workDir
+    cmd.Env = env
+    
+    var stdout, stderr bytes.Buffer
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    
+    if err := cmd.Run(); err != nil {
+        return nil, fmt.Errorf("go list failed: %v\n%s", err, stderr.String())
+    }
+    
+    g := &ImportGraph{
+        Packages:   make(map[string]*Package),
+        Dependents: make(map[string][]string),
+        dirs:       make(map[string]string),
+    }
+    
+    // go list -json emits a stream of objects, dependencies first and the
+    // main package last
+    dec := json.NewDecoder(&stdout)
+    for {
+        var pkg Package
+        if err := dec.Decode(&pkg); err == io.EOF {
+            break
+        } else if err != nil {
+            return nil, fmt.Errorf("failed to parse go list output: %v", err)
+        }
+        
+        g.Main = pkg.ImportPath
+        if pkg.Standard {
+            continue
+        }
+        
+        p := pkg
+        g.Packages[p.ImportPath] = &p
+        g.dirs[filepath.Clean(p.Dir)] = p.ImportPath
+    }
+    
+    for path, pkg := range g.Packages {
+        for _, imp := range pkg.Imports {
+            if _, ok := g.Packages[imp]; ok {
+                g.Dependents[imp] = append(g.Dependents[imp], path)
+            }
+        }
+    }
+    
+    return g, nil
+}
+
+// PackageOf returns the import path of the package in the graph that owns
+// filePath, or "" if the file is not part of the build
+func (g *ImportGraph) PackageOf(filePath string) string {
+    absPath, err := filepath.Abs(filePath)
+    if err != nil {
+        return ""
+    }
+    
+    if filepath.Ext(absPath) != ".go" {
+        return g.embeddingPackage(absPath)
+    }
+    
+    importPath, ok := g.dirs[filepath.Dir(absPath)]
+    if !ok {
+        return ""
+    }
+    
+    // Tests and files excluded by build constraints never end up in the
+    // binary. Other Go files in a package directory are assumed to be part
+    // of the build: a file created since the graph was loaded is not listed
+    // in GoFiles yet.
+    pkg := g.Packages[importPath]
+    base := filepath.Base(absPath)
+    if strings.HasSuffix(base, "_test.go") || contains(pkg.IgnoredGoFiles, base) {
+        return ""
+    }
+    
+    return importPath
+}
+
+// embeddingPackage returns the package that embeds absPath. EmbedFiles are
+// relative to the package directory and may be in subdirectories (e.g.
+// //go:embed assets/*.css), so every enclosing package is checked.
+func (g *ImportGraph) embeddingPackage(absPath string) string {
+    for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+        if importPath, ok := g.dirs[dir]; ok {
+            rel, err := filepath.Rel(dir, absPath)
+            if err == nil && contains(g.Packages[importPath].EmbedFiles, filepath.ToSlash(rel)) {
+                return importPath
+            }
+        }
+        if parent := filepath.Dir(dir); parent == dir {
+            return ""
+        }
+    }
+}
+
+func contains(list []string, s string) bool {
+    for _, v := range list {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,157 @@
+// cmd/dev-server/build/profile.go
+package build
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "time"
+)
+
+// DefaultProfileHistory is how many build profiles are kept
+const DefaultProfileHistory = 50
+
+// BuildProfile records where the time of one build went and the size of
+// the binary it produced. Durations are in milliseconds.
+type BuildProfile struct {
+    Time      time.Time `json:"time"`
+    Output    string    `json:"output"`
+    CacheHit  bool      `json:"cache_hit"`
+    LoadMs    int64     `json:"load_ms"`    // go list and go build package loading
+    CompileMs int64     `json:"compile_ms"` // compiling packages
+    LinkMs    int64     `json:"link_ms"`    // linking the binary
+    WriteMs   int64     `json:"write_ms"`   // moving the binary into place and caching it
+    TotalMs   int64     `json:"total_ms"`
+    Size      int64     `json:"size"`
+    SizeDelta int64     `json:"size_delta"` // change from the previous build
+}
+
+// buildTimes are the timestamps build collects for a profile
+type buildTimes struct {
+    start     time.Time // build requested
+    keyDone   time.Time // cache key computed (includes go list)
+    cmdStart  time.Time // go build started
+    cmdDone   time.Time // go build finished
+    done      time.Time // artifact in place and cached
+    graphFile string    // -debug-actiongraph output, if any
+}
+
+// SetProfiling turns collection of build profiles on or off
+func (c *Compiler) SetProfiling(enabled bool) {
+    c.profileMu.Lock()
+    defer c.profileMu.Unlock()
+    c.profiling = enabled
+}
+
+// Profiling reports whether build profiles are collected
+func (c *Compiler) Profiling() bool {
+    c.profileMu.Lock()
+    defer c.profileMu.Unlock()
+    return c.profiling
+}
+
+// Profiles returns the recorded build profiles, oldest first
+func (c *Compiler) Profiles() []BuildProfile {
+    c.profileMu.Lock()
+    defer c.profileMu.Unlock()
+    return append([]BuildProfile{}, c.profiles...)
+}
+
+// recordProfile adds the profile of a finished build to the history
+func (c *Compiler) recordProfile(t buildTimes, cacheHit bool, outputPath string) {
+    if !c.Profiling() {
+        return
+    }
+
+    p := BuildProfile{
+        Time:     t.start,
+        Output:   outputPath,
+        CacheHit: cacheHit,
+        LoadMs:   t.keyDone.Sub(t.start).Milliseconds(),
+        TotalMs:  t.done.Sub(t.start).Milliseconds(),
+    }
+
+    if !cacheHit {
+        p.WriteMs = t.done.Sub(t.cmdDone).Milliseconds()
+        p.CompileMs = t.cmdDone.Sub(t.cmdStart).Milliseconds()
+
+        // Split the go build run into its phases when the action graph
+        // is available
+        if phases, err := readActionGraph(t.graphFile); err == nil {
+            p.LoadMs += phases.firstStart.Sub(t.cmdStart).Milliseconds()
+            p.CompileMs = phases.linkStart.Sub(phases.firstStart).Milliseconds()
+            p.LinkMs = phases.linkDone.Sub(phases.linkStart).Milliseconds()
+            p.WriteMs = t.done.Sub(phases.linkDone).Milliseconds()
+        }
+    }
+
+    if info, err := os.Stat(outputPath); err == nil {
+        p.Size = info.Size()
+    }
+
+    c.profileMu.Lock()
+    defer c.profileMu.Unlock()
+
+    if n := len(c.profiles); n > 0 {
+        p.SizeDelta = p.Size - c.profiles[n-1].Size
+    }
+    c.profiles = append(c.profiles, p)
+    if len(c.profiles) > DefaultProfileHistory {
+        c.profiles = c.profiles[len(c.profiles)-DefaultProfileHistory:]
+    }
+}
+
+// actionPhases are the boundaries read from a go build action graph
+type actionPhases struct {
+    firstStart time.Time
+    linkStart  time.Time
+    linkDone   time.Time
+}
+
+// readActionGraph extracts phase boundaries from the file written by
+// go build -debug-actiongraph
+func readActionGraph(path string) (actionPhases, error) {
+    var phases actionPhases
+    if path == "" {
+        return phases, fmt.Errorf("no action graph")
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return phases, err
+    }
+
+    var actions []struct {
+        Mode      string
+        TimeStart time.Time
+        TimeDone  time.Time
+    }
+    if err := json.Unmarshal(data, &actions); err != nil {
+        return phases, err
+    }
+
+    for _, a := range actions {
+        if a.TimeStart.IsZero() {
+            continue
+        }
+        if phases.firstStart.IsZero() || a.TimeStart.Before(phases.firstStart) {
+            phases.firstStart = a.TimeStart
+        }
+        switch a.Mode {
+        case "link":
+            phases.linkStart = a.TimeStart
+            if a.TimeDone.After(phases.linkDone) {
+                phases.linkDone = a.TimeDone
+            }
+        case "link-install":
+            if a.TimeDone.After(phases.linkDone) {
+                phases.linkDone = a.TimeDone
+            }
+        }
+    }
+
+    if phases.linkStart.IsZero() {
+        return phases, fmt.Errorf("no link action in action graph")
+    }
+    return phases, nil
+}
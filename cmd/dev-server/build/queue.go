@@ -0,0 +1,307 @@
+// cmd/dev-server/build/queue.go
+package build
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+)
+
+// BuildState is the state of the build queue
+type BuildState string
+
+const (
+    StateIdle      BuildState = "idle"
+    StateQueued    BuildState = "queued"
+    StateRunning   BuildState = "running"
+    StateSucceeded BuildState = "succeeded"
+    StateFailed    BuildState = "failed"
+    StateSkipped   BuildState = "skipped"
+)
+
+// Request asks the queue for a build
+type Request struct {
+    Trigger string   // what caused the build, e.g. "file change"
+    Files   []string // changed files; empty for a full build
+    Force   bool     // bypass the dependency check and the cache
+}
+
+// Result describes a finished build
+type Result struct {
+    Request
+    Target     string // name of the target that was built
+    State      BuildState
+    OutputPath string
+    Err        error
+    Duration   time.Duration
+}
+
+// Queue serializes builds through a Compiler. At most one build runs at a
+// time; requests that arrive meanwhile are coalesced into one pending
+// request and the running build is canceled, since its output would be
+// stale anyway.
+type Queue struct {
+    compiler *Compiler
+    onState  func(BuildState)
+    onDone   func(Result)
+    
+    mu      sync.Mutex
+    state   BuildState
+    
+    // notifyMu serializes onState calls; notified is the state last
+    // reported
+    notifyMu sync.Mutex
+    notified BuildState
+    pending *Request
+    cancel  context.CancelFunc
+    idle    chan struct{}
+    wake    chan struct{}
+    stop    chan struct{}
+    done    chan struct{}
+}
+
+// NewQueue starts a build queue for compiler. onState is called on every state change
+// and onDone after each completed build; both may be nil.
+func NewQueue(compiler *Compiler, onState func(BuildState), onDone func(Result)) *Queue {
+    q := &Queue{
+        compiler: compiler,
+        onState:  onState,
+        onDone:   onDone,
+        state:    StateIdle,
+        notified: StateIdle,
+        idle:     make(chan struct{}),
+        wake:     make(chan struct{}, 1),
+        stop:     make(chan struct{}),
+        done:     make(chan struct{}),
+    }
+    close(q.idle)
+    
+    go q.run()
+    return q
+}
+
+// Submit queues a build request, merging it with any pending request and
+// canceling the build in progress
+func (q *Queue) Submit(req Request) {
+    q.mu.Lock()
+    q.pending = merge(q.pending, &req)
+    if q.cancel != nil {
+        q.cancel()
+    }
+    q.markBusy()
+    q.state = StateQueued
+    q.mu.Unlock()
+    q.notify()
+    
+    select {
+    case q.wake <- struct{}{}:
+    default:
+    }
+}
+
+// SetCompiler switches the queue to another compiler (build target). The
+// build in progress is canceled; callers submit a request for the new
+// target.
+func (q *Queue) SetCompiler(compiler *Compiler) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    q.compiler = compiler
+    if q.cancel != nil {
+        q.cancel()
+    }
+}
+
+// State returns the current state of the queue
+func (q *Queue) State() BuildState {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return q.state
+}
+
+// Wait blocks until no build is queued or running
+func (q *Queue) Wait(ctx context.Context) error {
+    q.mu.Lock()
+    idle := q.idle
+    q.mu.Unlock()
+    
+    select {
+    case <-idle:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+// Close cancels the running build and stops the queue. Pending requests
+// are dropped and Wait callers released.
+func (q *Queue) Close() {
+    q.mu.Lock()
+    if q.cancel != nil {
+        q.cancel()
+    }
+    q.mu.Unlock()
+    
+    close(q.stop)
+    <-q.done
+    
+    q.mu.Lock()
+    q.pending = nil
+    q.markIdle()
+    q.mu.Unlock()
+}
+
+func (q *Queue) run() {
+    defer close(q.done)
+    
+    for {
+        select {
+        case <-q.stop:
+            return
+        case <-q.wake:
+        }
+    
+        for {
+            select {
+            case <-q.stop:
+                return
+            default:
+            }
+            
+            q.mu.Lock()
+            req := q.pending
+            q.pending = nil
+            if req == nil {
+                q.markIdle()
+                q.mu.Unlock()
+                break
+            }
+            ctx, cancel := context.WithCancel(context.Background())
+            q.cancel = cancel
+            compiler := q.compiler
+            q.state = StateRunning
+            q.mu.Unlock()
+            q.notify()
+    
+            result := q.build(ctx, compiler, *req)
+            cancel()
+    
+            q.mu.Lock()
+            q.cancel = nil
+            canceled := errors.Is(result.Err, context.Canceled)
+            if canceled {
+                // Superseded: fold this request into the next one so its
+                // files are not lost
+                q.pending = merge(req, q.pending)
+            } else if q.pending == nil {
+                // A request that arrived meanwhile stays queued
+                q.state = result.State
+            }
+            q.mu.Unlock()
+    
+            if canceled {
+                continue
+            }
+    
+            q.notify()
+            if q.onDone != nil {
+                q.onDone(result)
+            }
+        }
+    }
+}
+
+// build runs one request through the compiler. Full builds may be
+// answered from the cache (switching back to a target is instant); only
+// forced builds bypass it.
+func (q *Queue) build(ctx context.Context, compiler *Compiler, req Request) Result {
+    start := time.Now()
+    result := Result{Request: req, Target: compiler.Target().Name}
+    
+    switch {
+    case req.Force:
+        result.OutputPath, result.Err = compiler.Rebuild(ctx)
+    case len(req.Files) == 0:
+        result.OutputPath, result.Err = compiler.Build(ctx)
+    default:
+        result.OutputPath, result.Err = compiler.BuildOnlyChanged(ctx, req.Files)
+    }
+    result.Duration = time.Since(start)
+    
+    switch {
+    case result.Err != nil:
+        if ctx.Err() != nil {
+            result.Err = ctx.Err()
+        }
+        result.State = StateFailed
+    case result.OutputPath == "":
+        result.State = StateSkipped
+    default:
+        result.State = StateSucceeded
+    }
+    return result
+}
+
+// notify reports the current state if it changed since the last report.
+// States are changed under q.mu together with the requests they describe
+// and reported afterwards; since notify reads the latest state, reports
+// racing each other cannot leave a stale state behind.
+func (q *Queue) notify() {
+    q.notifyMu.Lock()
+    defer q.notifyMu.Unlock()
+    
+    state := q.State()
+    if state == q.notified {
+        return
+    }
+    q.notified = state
+    if q.onState != nil {
+        q.onState(state)
+    }
+}
+
+// markBusy opens a new idle channel if the queue was idle. Callers must hold q.mu.
+func (q *Queue) markBusy() {
+    select {
+    case <-q.idle:
+        q.idle = make(chan struct{})
+    default:
+    }
+}
+
+// markIdle releases Wait callers. Callers must hold q.mu.
+func (q *Queue) markIdle() {
+    select {
+    case <-q.idle:
+    default:
+        close(q.idle)
+    }
+}
+
+// merge coalesces two requests into one
+func merge(a, b *Request) *Request {
+    if a == nil {
+        return b
+    }
+    if b == nil {
+        return a
+    }
+    
+    merged := &Request{
+        Trigger: b.Trigger,
+        Force:   a.Force || b.Force,
+    }
+    
+    // An empty file list means a full build, which covers everything
+    if len(a.Files) > 0 && len(b.Files) > 0 {
+        seen := make(map[string]bool)
+        for _, f := range append(append([]string{}, a.Files...), b.Files...) {
+            if !seen[f] {
+                seen[f] = true
+                merged.Files = append(merged.Files, f)
+            }
+        }
+    }
+    
+    return merged
+}
@@ -0,0 +1,81 @@
+// cmd/dev-server/build/target.go
+package build
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+)
+
+// Target is one WebAssembly entry point the dev server can build, e.g.
+//
+//	{"name": "example", "package": "./cmd/example-app", "ldflags": "-s -w"}
+//
+// Each target has its own output directory and build cache. Targets are
+// built with the Go toolchain unless go_binary names TinyGo:
+//
+//	{"name": "tinygo", "package": "./cmd/wasm", "tags": ["dev"], "go_binary": "tinygo"}
+type Target struct {
+    Name     string   `json:"name"`
+    Package  string   `json:"package"` // main package, relative to the module root
+    Tags     []string `json:"tags"`
+    Ldflags  string   `json:"ldflags"`
+    Output   string   `json:"output"`    // directory for app-*.wasm, relative to the module root
+    GoBinary string   `json:"go_binary"` // "go" (default), "tinygo" or a path to either
+    WasmExec string   `json:"wasm_exec"` // runtime shim, default the toolchain's wasm_exec.js
+}
+
+// TinyGo reports whether the target is built with TinyGo
+func (t Target) TinyGo() bool {
+    return strings.Contains(filepath.Base(t.GoBinary), "tinygo")
+}
+
+// DefaultTarget is the storybook, built into web/ as before targets existed
+func DefaultTarget() Target {
+    return Target{
+        Name:    "storybook",
+        Package: "./cmd/wasm",
+        Tags:    []string{"dev"},
+        Ldflags: "-s -w",
+        Output:  "web",
+    }
+}
+
+// OutputDir returns the target's output directory below workDir. Targets
+// without one get web/targets/<name>.
+func (t Target) OutputDir(workDir string) string {
+    if t.Output == "" {
+        return filepath.Join(workDir, "web", "targets", t.Name)
+    }
+    return filepath.Join(workDir, filepath.FromSlash(t.Output))
+}
+
+// ValidateTargets checks that targets are named uniquely and do not share
+// output directories, where they would remove each other's artifacts
+func ValidateTargets(targets []Target) error {
+    if len(targets) == 0 {
+        return fmt.Errorf("no build targets configured")
+    }
+
+    names := make(map[string]bool)
+    outputs := make(map[string]string)
+    for _, t := range targets {
+        if t.Name == "" {
+            return fmt.Errorf("build target for %q has no name", t.Package)
+        }
+        if t.Package == "" {
+            return fmt.Errorf("build target %q has no package", t.Name)
+        }
+        if names[t.Name] {
+            return fmt.Errorf("duplicate build target %q", t.Name)
+        }
+        names[t.Name] = true
+
+        dir := t.OutputDir("")
+        if other, ok := outputs[dir]; ok {
+            return fmt.Errorf("build targets %q and %q share the output directory %s", other, t.Name, dir)
+        }
+        outputs[dir] = t.Name
+    }
+    return nil
+}
@@ -0,0 +1,166 @@
+// cmd/dev-server/build/tinygo.go
+package build
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// WasmExecPath returns the JavaScript runtime shim (wasm_exec.js) matching
+// the toolchain that builds the target. Go and TinyGo binaries only run
+// with the shim of their own toolchain and version.
+func (c *Compiler) WasmExecPath() (string, error) {
+    c.wasmExecMu.Lock()
+    cached := c.wasmExec
+    c.wasmExecMu.Unlock()
+    if cached != "" {
+        return cached, nil
+    }
+
+    var path string
+    switch {
+    case c.target.WasmExec != "":
+        path = filepath.Join(c.workDir, filepath.FromSlash(c.target.WasmExec))
+    case c.target.TinyGo():
+        root, err := c.toolEnv("TINYGOROOT")
+        if err != nil {
+            return "", err
+        }
+        path = filepath.Join(root, "targets", "wasm_exec.js")
+    default:
+        root, err := c.toolEnv("GOROOT")
+        if err != nil {
+            return "", err
+        }
+        // Go 1.24 moved the shim from misc/wasm to lib/wasm
+        path = filepath.Join(root, "lib", "wasm", "wasm_exec.js")
+        if !fileExists(path) {
+            path = filepath.Join(root, "misc", "wasm", "wasm_exec.js")
+        }
+    }
+
+    if !fileExists(path) {
+        return "", fmt.Errorf("wasm_exec.js not found at %s", path)
+    }
+
+    c.wasmExecMu.Lock()
+    c.wasmExec = path
+    c.wasmExecMu.Unlock()
+    return path, nil
+}
+
+// toolEnv returns a variable reported by `<goBinary> env`
+func (c *Compiler) toolEnv(name string) (string, error) {
+    out, err := exec.Command(c.goBinary, "env", name).Output()
+    if err != nil {
+        return "", fmt.Errorf("%s env %s failed: %v", c.goBinary, name, err)
+    }
+    value := strings.TrimSpace(string(out))
+    if value == "" {
+        return "", fmt.Errorf("%s env %s is empty", c.goBinary, name)
+    }
+    return value, nil
+}
+
+// CompatResult is the outcome of compiling one package with TinyGo
+type CompatResult struct {
+    Package     string       `json:"package"`
+    OK          bool         `json:"ok"`
+    DurationMs  int64        `json:"duration_ms"`
+    Output      string       `json:"output,omitempty"`
+    Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// CheckTinyGo compiles every non-main package matched by patterns (e.g.
+// "./pkg/components/...") for the browser with TinyGo and reports which
+// fail. Each package is built through a generated main package that
+// imports it, since TinyGo only builds programs.
+func CheckTinyGo(ctx context.Context, workDir, tinygo string, tags []string, patterns ...string) ([]CompatResult, error) {
+    if _, err := exec.LookPath(tinygo); err != nil {
+        return nil, fmt.Errorf("TinyGo is not installed: %v", err)
+    }
+
+    absWorkDir, err := filepath.Abs(workDir)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get absolute path: %v", err)
+    }
+
+    // The Go toolchain lists the packages, as for TinyGo targets
+    listTags := append(append([]string{}, tags...), "tinygo")
+    args := append([]string{"list",
+        "-tags", joinTags(listTags),
+        "-f", `{{if ne .Name "main"}}{{.ImportPath}}{{end}}`,
+    }, patterns...)
+    cmd := exec.CommandContext(ctx, "go", args...)
+    cmd.Dir = absWorkDir
+    cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+
+    var stderr bytes.Buffer
+    cmd.Stderr = &stderr
+    out, err := cmd.Output()
+    if err != nil {
+        return nil, fmt.Errorf("go list failed: %v\n%s", err, stderr.String())
+    }
+
+    // The generated program lives inside the module so that it resolves
+    // the module's packages. The dot keeps the watcher and ./... away.
+    dir, err := os.MkdirTemp(absWorkDir, ".tinygo-check-")
+    if err != nil {
+        return nil, err
+    }
+    defer os.RemoveAll(dir)
+
+    var results []CompatResult
+    for _, pkg := range strings.Fields(string(out)) {
+        if ctx.Err() != nil {
+            return results, ctx.Err()
+        }
+        results = append(results, checkPackage(ctx, absWorkDir, dir, tinygo, tags, pkg))
+    }
+    return results, nil
+}
+
+// checkPackage builds a program importing pkg in dir
+func checkPackage(ctx context.Context, workDir, dir, tinygo string, tags []string, pkg string) CompatResult {
+    result := CompatResult{Package: pkg}
+
+    source := fmt.Sprintf("package main\n\nimport _ %q\n\nfunc main() {}\n", pkg)
+    if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+        result.Output = err.Error()
+        return result
+    }
+
+    cmd := exec.CommandContext(ctx, tinygo, "build",
+        "-o", filepath.Join(dir, "check.wasm"),
+        "-target", "wasm",
+        "-tags", joinTags(tags),
+        "./"+filepath.Base(dir),
+    )
+    cmd.Dir = workDir
+
+    var output bytes.Buffer
+    cmd.Stdout = &output
+    cmd.Stderr = &output
+
+    start := time.Now()
+    err := cmd.Run()
+    result.DurationMs = time.Since(start).Milliseconds()
+
+    if err != nil {
+        result.Output = strings.TrimSpace(output.String())
+        if result.Output == "" {
+            result.Output = err.Error()
+        }
+        result.Diagnostics = ParseDiagnostics(output.String(), workDir)
+        return result
+    }
+
+    result.OK = true
+    return result
+}
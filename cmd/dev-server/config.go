@@ -0,0 +1,154 @@
+// cmd/dev-server/config.go
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/build"
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/watch"
+)
+
+// defaultConfigFile is looked up in the working directory when -config is
+// not given
+const defaultConfigFile = "devserver.json"
+
+// Config holds the dev server settings. It is read from a JSON file, e.g.
+//
+//	{
+//	    "port": 8080,
+//	    "dashboard": true,
+//	    "watch": {
+//	        "enabled": true,
+//	        "include": ["pkg/**", "cmd/wasm/**", "web/**"],
+//	        "exclude": ["**/testdata"],
+//	        "extensions": [".go", ".css", ".html"],
+//	        "debounce": "200ms",
+//	        "backend": "poll",
+//	        "poll_interval": "1s"
+//	    },
+//	    "target": "storybook",
+//	    "targets": [
+//	        {"name": "storybook", "package": "./cmd/wasm", "tags": ["dev"], "ldflags": "-s -w", "output": "web"},
+//	        {"name": "example", "package": "./cmd/example-app", "ldflags": "-s -w"},
+//	        {"name": "tinygo", "package": "./cmd/wasm", "tags": ["dev"], "go_binary": "tinygo"}
+//	    ]
+//	}
+//
+// and command line flags override the file. Without targets only the
+// storybook is built; the active target can be switched from the dashboard.
+type Config struct {
+    Port      int         `json:"port"`
+    Dir       string      `json:"dir"`
+    Dashboard bool        `json:"dashboard"`
+    Profile   bool        `json:"profile"`
+    Editor    string      `json:"editor"`
+    Watch     WatchConfig `json:"watch"`
+    Target    string      `json:"target"` // initially active target, default the first
+    Targets   []build.Target `json:"targets"`
+}
+
+// WatchConfig configures file watching
+type WatchConfig struct {
+    Enabled      bool     `json:"enabled"`
+    Include      []string `json:"include"`
+    Exclude      []string `json:"exclude"` // added to the default excludes
+    Extensions   []string `json:"extensions"`
+    Debounce     Duration `json:"debounce"`
+    Backend      string   `json:"backend"` // auto, fsnotify or poll
+    PollInterval Duration `json:"poll_interval"`
+}
+
+// Options converts the configuration to watcher options. The configured
+// excludes extend the defaults (.git, node_modules and dot directories,
+// which include the server's own temporary directories).
+func (c WatchConfig) Options() watch.Options {
+    return watch.Options{
+        Include:      c.Include,
+        Exclude:      append(watch.DefaultOptions().Exclude, c.Exclude...),
+        Extensions:   c.Extensions,
+        Debounce:     time.Duration(c.Debounce),
+        Backend:      c.Backend,
+        PollInterval: time.Duration(c.PollInterval),
+    }
+}
+
+// Duration is a time.Duration written as a string in JSON, e.g. "150ms"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+    return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+    var s string
+    if err := json.Unmarshal(b, &s); err != nil {
+        return fmt.Errorf("duration must be a string like \"100ms\": %v", err)
+    }
+    v, err := time.ParseDuration(s)
+    if err != nil {
+        return err
+    }
+    *d = Duration(v)
+    return nil
+}
+
+// defaultConfig returns the settings used when nothing is configured
+func defaultConfig() Config {
+    opts := watch.DefaultOptions()
+    return Config{
+        Port:   8080,
+        Dir:    ".",
+        Editor: "vscode://file/{file}:{line}:{column}",
+        Watch: WatchConfig{
+            Enabled:      true,
+            Extensions:   opts.Extensions,
+            Debounce:     Duration(opts.Debounce),
+            Backend:      opts.Backend,
+            PollInterval: Duration(opts.PollInterval),
+        },
+    }
+}
+
+// loadConfig reads the config file at path over the defaults. A missing
+// file is only an error when required is set.
+func loadConfig(path string, required bool) (Config, error) {
+    cfg := defaultConfig()
+
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) && !required {
+        return cfg, nil
+    }
+    if err != nil {
+        return cfg, fmt.Errorf("failed to read config: %v", err)
+    }
+
+    if err := json.Unmarshal(data, &cfg); err != nil {
+        return cfg, fmt.Errorf("failed to parse %s: %v", path, err)
+    }
+    return cfg, nil
+}
+
+// buildTargets returns the configured build targets, or the storybook.
+// Defaults are applied here rather than in defaultConfig because JSON
+// would decode the configured targets over the default one.
+func (c Config) buildTargets() []build.Target {
+    if len(c.Targets) == 0 {
+        return []build.Target{build.DefaultTarget()}
+    }
+    return c.Targets
+}
+
+// splitList splits a comma separated flag value
+func splitList(s string) []string {
+    var list []string
+    for _, item := range strings.Split(s, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            list = append(list, item)
+        }
+    }
+    return list
+}
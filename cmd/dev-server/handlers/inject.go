@@ -0,0 +1,90 @@
+// cmd/dev-server/handlers/inject.go
+package handlers
+
+import (
+    "bytes"
+    "net/http"
+    "path"
+    "strconv"
+    "strings"
+)
+
+// InjectScript wraps next so that every HTML response it produces loads the
+// script at src, inserted right before </body>. Other responses pass
+// through untouched.
+func InjectScript(next http.Handler, src string) http.Handler {
+    tag := []byte(`<script src="` + src + `"></script>`)
+
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        // Ranges and revalidation would apply to the unmodified file.
+        // Other files keep them.
+        if servesHTML(r.URL.Path) {
+            r.Header.Del("Range")
+            r.Header.Del("If-Modified-Since")
+            r.Header.Del("If-None-Match")
+        }
+
+        rec := &htmlRecorder{ResponseWriter: w}
+        next.ServeHTTP(rec, r)
+
+        if !rec.html {
+            return
+        }
+
+        body := rec.body.Bytes()
+        if i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>")); i >= 0 {
+            body = append(body[:i:i], append(tag, body[i:]...)...)
+        } else {
+            body = append(body, tag...)
+        }
+
+        w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+        w.Header().Set("Cache-Control", "no-cache")
+        w.WriteHeader(rec.status)
+        w.Write(body)
+    })
+}
+
+// servesHTML reports whether a file server answers path with an HTML page:
+// a directory index or an .html file
+func servesHTML(urlPath string) bool {
+    if strings.HasSuffix(urlPath, "/") {
+        return true
+    }
+    ext := strings.ToLower(path.Ext(urlPath))
+    return ext == ".html" || ext == ".htm"
+}
+
+// htmlRecorder buffers HTML responses and streams everything else
+type htmlRecorder struct {
+    http.ResponseWriter
+    html        bool
+    status      int
+    body        bytes.Buffer
+    wroteHeader bool
+}
+
+func (r *htmlRecorder) WriteHeader(status int) {
+    if r.wroteHeader {
+        return
+    }
+    r.wroteHeader = true
+    r.status = status
+
+    if strings.HasPrefix(r.Header().Get("Content-Type"), "text/html") {
+        r.html = true
+        r.Header().Del("Content-Length")
+        return
+    }
+    r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *htmlRecorder) Write(b []byte) (int, error) {
+    if !r.wroteHeader {
+        r.WriteHeader(http.StatusOK)
+    }
+    if r.html {
+        return r.body.Write(b)
+    }
+    return r.ResponseWriter.Write(b)
+}
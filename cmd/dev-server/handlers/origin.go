@@ -0,0 +1,24 @@
+// cmd/dev-server/handlers/origin.go
+package handlers
+
+import (
+    "net/http"
+    "net/url"
+)
+
+// SameOrigin wraps next so that browsers can only call it from pages served
+// by this server. Any page can make a browser send a POST to localhost,
+// whatever its CORS headers say; the Origin header tells those requests
+// apart. Requests without one, e.g. from curl or an editor, pass through.
+func SameOrigin(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if origin := r.Header.Get("Origin"); origin != "" {
+            u, err := url.Parse(origin)
+            if err != nil || u.Host != r.Host {
+                http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+                return
+            }
+        }
+        next.ServeHTTP(w, r)
+    })
+}
@@ -2,24 +2,88 @@
 package handlers
 
 import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
     "log"
     "net/http"
+    "sort"
     "sync"
     "time"
-    
+
     "github.com/gorilla/websocket"
 )
 
+const (
+    // sendQueueSize is how many messages may wait for a slow client before
+    // it is disconnected
+    sendQueueSize = 64
+
+    // writeWait is the time allowed to write a message to a client
+    writeWait = 10 * time.Second
+)
+
+// ErrUnknownClient is returned when sending to a client that is not connected
+var ErrUnknownClient = errors.New("unknown client")
+
+// Message is a message received from a client. Clients send
+//
+//	{"type": "register", "data": {"kind": "page", "url": "...", "component": "...", "story": "..."}}
+//
+// when they connect and whenever their page or story changes; other types
+// are passed to OnMessage.
+type Message struct {
+    Type string          `json:"type"`
+    Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ClientInfo describes a connected client
+type ClientInfo struct {
+    ID          string    `json:"id"`
+    Kind        string    `json:"kind"` // "page" or "dashboard"
+    URL         string    `json:"url"`
+    Component   string    `json:"component,omitempty"`
+    Story       string    `json:"story,omitempty"`
+    UserAgent   string    `json:"user_agent"`
+    RemoteAddr  string    `json:"remote_addr"`
+    ConnectedAt time.Time `json:"connected_at"`
+}
+
+// client is one connection. Only its writer goroutine writes to conn;
+// everything else goes through the send queue.
+type client struct {
+    conn *websocket.Conn
+    send chan []byte
+    info ClientInfo
+
+    // closeCode and closeReason go into the close frame sent once send is
+    // closed; they are set before closing it
+    closeCode   int
+    closeReason string
+}
+
 type LiveReloadServer struct {
-    clients    map[*websocket.Conn]bool
+    clients    map[string]*client
     clientsMu  sync.RWMutex
     upgrader   websocket.Upgrader
     pingPeriod time.Duration
+    writers    sync.WaitGroup // running writePumps
+
+    // OnClientsChange, if set, is called when a client connects,
+    // disconnects or registers
+    OnClientsChange func()
+
+    // OnRegister, if set, is called after a client registered
+    OnRegister func(info ClientInfo)
+
+    // OnMessage, if set, is called for client messages other than register
+    OnMessage func(from ClientInfo, msg Message)
 }
 
 func NewLiveReloadServer() *LiveReloadServer {
     return &LiveReloadServer{
-        clients: make(map[*websocket.Conn]bool),
+        clients: make(map[string]*client),
         upgrader: websocket.Upgrader{
             CheckOrigin: func(r *http.Request) bool { return true },
         },
@@ -33,121 +97,248 @@ func (s *LiveReloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
         log.Printf("WebSocket upgrade failed: %v", err)
         return
     }
-    defer conn.Close()
-    
+
+    c := &client{
+        conn:      conn,
+        send:      make(chan []byte, sendQueueSize),
+        closeCode: websocket.CloseNormalClosure,
+        info: ClientInfo{
+            ID:          newClientID(),
+            Kind:        "page",
+            UserAgent:   r.UserAgent(),
+            RemoteAddr:  r.RemoteAddr,
+            ConnectedAt: time.Now(),
+        },
+    }
+
     // Register client
     s.clientsMu.Lock()
-    s.clients[conn] = true
+    s.clients[c.info.ID] = c
     s.clientsMu.Unlock()
-    
-    // Start ping/pong to keep connection alive
-    go s.keepAlive(conn)
-    
-    // Wait for client disconnect
+    s.clientsChanged()
+
+    s.writers.Add(1)
+    go s.writePump(c)
+    s.readPump(c)
+
+    // Unregister client
+    s.removeClient(c.info.ID)
+}
+
+// readPump handles incoming messages until the connection fails. Pongs
+// extend the read deadline, so a client that stops answering pings is
+// dropped.
+func (s *LiveReloadServer) readPump(c *client) {
+    pongWait := s.pingPeriod * 2
+    c.conn.SetReadDeadline(time.Now().Add(pongWait))
+    c.conn.SetPongHandler(func(string) error {
+        return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+    })
+
     for {
-        _, _, err := conn.ReadMessage()
+        _, data, err := c.conn.ReadMessage()
         if err != nil {
-            break
+            return
+        }
+
+        var msg Message
+        if err := json.Unmarshal(data, &msg); err != nil {
+            log.Printf("Ignoring malformed client message: %v", err)
+            continue
+        }
+
+        if msg.Type == "register" {
+            s.register(c.info.ID, msg.Data)
+            continue
+        }
+
+        if s.OnMessage != nil {
+            if info, ok := s.Client(c.info.ID); ok {
+                s.OnMessage(info, msg)
+            }
         }
     }
-    
-    // Unregister client
-    s.clientsMu.Lock()
-    delete(s.clients, conn)
-    s.clientsMu.Unlock()
 }
 
-// keepAlive sends periodic ping messages to keep the WebSocket connection alive
-func (s *LiveReloadServer) keepAlive(conn *websocket.Conn) {
+// writePump is the only writer of the connection. It sends queued messages
+// and keeps the connection alive with pings.
+func (s *LiveReloadServer) writePump(c *client) {
     ticker := time.NewTicker(s.pingPeriod)
-    defer ticker.Stop()
-    
-    // Configure connection for pong handling
-    conn.SetPongHandler(func(appData string) error {
-        // Reset read deadline on pong
-        conn.SetReadDeadline(time.Now().Add(s.pingPeriod * 2))
-        return nil
-    })
-    
+    defer func() {
+        ticker.Stop()
+        c.conn.Close()
+        s.writers.Done()
+    }()
+
     for {
         select {
+        case data, ok := <-c.send:
+            c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+            if !ok {
+                // The server closed the queue
+                c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeReason))
+                return
+            }
+            if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+                log.Printf("Failed to send message: %v", err)
+                return
+            }
+
         case <-ticker.C:
-            // Set write deadline for ping
-            conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-            if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-                // If ping fails, remove client and exit goroutine
+            c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+            if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
                 log.Printf("Failed to send ping: %v", err)
-                s.removeClient(conn)
                 return
             }
-            
-            // Set read deadline expecting pong
-            conn.SetReadDeadline(time.Now().Add(s.pingPeriod * 2))
         }
     }
 }
 
-// removeClient safely removes a client from the connections map
-func (s *LiveReloadServer) removeClient(conn *websocket.Conn) {
+// register updates a client's metadata from a register message
+func (s *LiveReloadServer) register(id string, data json.RawMessage) {
+    var update struct {
+        Kind      string `json:"kind"`
+        URL       string `json:"url"`
+        Component string `json:"component"`
+        Story     string `json:"story"`
+    }
+    if err := json.Unmarshal(data, &update); err != nil {
+        log.Printf("Ignoring malformed register message: %v", err)
+        return
+    }
+
     s.clientsMu.Lock()
-    defer s.clientsMu.Unlock()
-    
-    if _, exists := s.clients[conn]; exists {
-        delete(s.clients, conn)
-        conn.Close()
+    c, ok := s.clients[id]
+    var info ClientInfo
+    if ok {
+        if update.Kind != "" {
+            c.info.Kind = update.Kind
+        }
+        c.info.URL = update.URL
+        c.info.Component = update.Component
+        c.info.Story = update.Story
+        info = c.info
+    }
+    s.clientsMu.Unlock()
+
+    if ok {
+        s.clientsChanged()
+        if s.OnRegister != nil {
+            s.OnRegister(info)
+        }
     }
 }
 
-func (s *LiveReloadServer) BroadcastReload(reason string) {
-    s.clientsMu.RLock()
-    defer s.clientsMu.RUnlock()
-    
-    if len(s.clients) == 0 {
-        return
+// removeClient unregisters a client and stops its writer
+func (s *LiveReloadServer) removeClient(id string) {
+    s.clientsMu.Lock()
+    c, ok := s.clients[id]
+    if ok {
+        delete(s.clients, id)
+        close(c.send)
     }
-    
-    message := map[string]interface{}{
-        "type":   "reload",
-        "reason": reason,
-        "time":   time.Now().Unix(),
-    }
-    
-    for client := range s.clients {
-        go func(c *websocket.Conn) {
-            // Set write deadline for broadcast
-            c.SetWriteDeadline(time.Now().Add(10 * time.Second))
-            if err := c.WriteJSON(message); err != nil {
-                log.Printf("Failed to send reload message: %v", err)
-                s.removeClient(c)
-            }
-        }(client)
+    s.clientsMu.Unlock()
+
+    if ok {
+        s.clientsChanged()
+    }
+}
+
+func (s *LiveReloadServer) clientsChanged() {
+    if s.OnClientsChange != nil {
+        s.OnClientsChange()
     }
 }
 
 // BroadcastMessage sends a custom JSON message to all connected clients
 func (s *LiveReloadServer) BroadcastMessage(messageType string, data interface{}) {
+    s.broadcast(newMessage(messageType, data), nil)
+}
+
+// SendTo sends a custom JSON message to the client with the given ID
+func (s *LiveReloadServer) SendTo(id, messageType string, data interface{}) error {
+    sent := s.broadcast(newMessage(messageType, data), func(info ClientInfo) bool {
+        return info.ID == id
+    })
+    if sent == 0 {
+        return ErrUnknownClient
+    }
+    return nil
+}
+
+// SendWhere sends a custom JSON message to the clients accepted by match
+// and returns how many clients it was queued for
+func (s *LiveReloadServer) SendWhere(match func(ClientInfo) bool, messageType string, data interface{}) int {
+    return s.broadcast(newMessage(messageType, data), match)
+}
+
+// broadcast queues message for every client accepted by match (all when
+// match is nil). Clients whose queue is full are disconnected.
+func (s *LiveReloadServer) broadcast(message interface{}, match func(ClientInfo) bool) int {
+    data, err := json.Marshal(message)
+    if err != nil {
+        log.Printf("Failed to encode message: %v", err)
+        return 0
+    }
+
+    var sent int
+    var slow []string
+
     s.clientsMu.RLock()
-    defer s.clientsMu.RUnlock()
-    
-    if len(s.clients) == 0 {
-        return
+    for id, c := range s.clients {
+        if match != nil && !match(c.info) {
+            continue
+        }
+        select {
+        case c.send <- data:
+            sent++
+        default:
+            slow = append(slow, id)
+        }
     }
-    
-    message := map[string]interface{}{
+    s.clientsMu.RUnlock()
+
+    for _, id := range slow {
+        log.Printf("Client %s is not keeping up, disconnecting", id)
+        s.removeClient(id)
+    }
+
+    return sent
+}
+
+func newMessage(messageType string, data interface{}) map[string]interface{} {
+    return map[string]interface{}{
         "type": messageType,
         "data": data,
         "time": time.Now().Unix(),
     }
-    
-    for client := range s.clients {
-        go func(c *websocket.Conn) {
-            c.SetWriteDeadline(time.Now().Add(10 * time.Second))
-            if err := c.WriteJSON(message); err != nil {
-                log.Printf("Failed to send message: %v", err)
-                s.removeClient(c)
-            }
-        }(client)
+}
+
+// Client returns the metadata of the client with the given ID
+func (s *LiveReloadServer) Client(id string) (ClientInfo, bool) {
+    s.clientsMu.RLock()
+    defer s.clientsMu.RUnlock()
+
+    c, ok := s.clients[id]
+    if !ok {
+        return ClientInfo{}, false
+    }
+    return c.info, true
+}
+
+// Clients returns the metadata of all connected clients, oldest first
+func (s *LiveReloadServer) Clients() []ClientInfo {
+    s.clientsMu.RLock()
+    infos := make([]ClientInfo, 0, len(s.clients))
+    for _, c := range s.clients {
+        infos = append(infos, c.info)
     }
+    s.clientsMu.RUnlock()
+
+    sort.Slice(infos, func(i, j int) bool {
+        return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+    })
+    return infos
 }
 
 // GetClientCount returns the number of currently connected clients
@@ -157,13 +348,26 @@ func (s *LiveReloadServer) GetClientCount() int {
     return len(s.clients)
 }
 
-// CloseAll closes all WebSocket connections
-func (s *LiveReloadServer) CloseAll() {
+// CloseAll closes all WebSocket connections with a "going away" close
+// frame carrying reason, which tells the live reload client to reconnect
+// once the server is back. It returns when every close frame was written.
+func (s *LiveReloadServer) CloseAll(reason string) {
     s.clientsMu.Lock()
-    defer s.clientsMu.Unlock()
-    
-    for client := range s.clients {
-        client.Close()
+    for id, c := range s.clients {
+        c.closeCode = websocket.CloseGoingAway
+        c.closeReason = reason
+        close(c.send)
+        delete(s.clients, id)
     }
-    s.clients = make(map[*websocket.Conn]bool)
+    s.clientsMu.Unlock()
+
+    s.clientsChanged()
+    s.writers.Wait()
+}
+
+// newClientID returns a random client identifier
+func newClientID() string {
+    b := make([]byte, 8)
+    rand.Read(b)
+    return hex.EncodeToString(b)
 }
@@ -0,0 +1,265 @@
+// cmd/dev-server/history.go
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/build"
+)
+
+const (
+    // historySize is how many builds the history keeps
+    historySize = 100
+
+    // eventQueueSize is how many events may wait for a slow subscriber
+    // before it is dropped
+    eventQueueSize = 32
+
+    // eventKeepAlive is how often idle event streams get a comment, so
+    // that proxies do not close them
+    eventKeepAlive = 30 * time.Second
+)
+
+// BuildRecord is one finished build
+type BuildRecord struct {
+    ID          int                `json:"id"`
+    Target      string             `json:"target"`
+    Trigger     string             `json:"trigger"`
+    Files       []string           `json:"files"`
+    Started     time.Time          `json:"started"`
+    DurationMs  int64              `json:"duration_ms"`
+    Status      build.BuildState   `json:"status"`
+    CacheHit    bool               `json:"cache_hit"`
+    Output      string             `json:"output,omitempty"` // artifact path
+    Size        int64              `json:"size,omitempty"`   // artifact size in bytes
+    Error       string             `json:"error,omitempty"`
+    Diagnostics []build.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// BuildEvent is sent to event stream subscribers: "state" events carry the
+// build queue state, "build" events a finished BuildRecord
+type BuildEvent struct {
+    Type string
+    Data interface{}
+}
+
+// BuildHistory keeps the most recent builds and fans build events out to
+// subscribers. It is independent of the dashboard data, which is cleared
+// after every successful build.
+type BuildHistory struct {
+    mu          sync.RWMutex
+    records     []BuildRecord
+    nextID      int
+    subscribers map[chan BuildEvent]struct{}
+    closed      bool
+}
+
+// NewBuildHistory returns an empty history
+func NewBuildHistory() *BuildHistory {
+    return &BuildHistory{
+        nextID:      1,
+        subscribers: make(map[chan BuildEvent]struct{}),
+    }
+}
+
+// Add assigns the record an ID, stores it and publishes it
+func (h *BuildHistory) Add(record BuildRecord) BuildRecord {
+    h.mu.Lock()
+    record.ID = h.nextID
+    h.nextID++
+    h.records = append(h.records, record)
+    if len(h.records) > historySize {
+        h.records = h.records[len(h.records)-historySize:]
+    }
+    h.mu.Unlock()
+
+    h.Publish(BuildEvent{Type: "build", Data: record})
+    return record
+}
+
+// List returns up to limit records, newest first (all when limit <= 0)
+func (h *BuildHistory) List(limit int) []BuildRecord {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+
+    if limit <= 0 || limit > len(h.records) {
+        limit = len(h.records)
+    }
+    records := make([]BuildRecord, 0, limit)
+    for i := len(h.records) - 1; i >= 0 && len(records) < limit; i-- {
+        records = append(records, h.records[i])
+    }
+    return records
+}
+
+// Get returns the record with the given ID, if it is still kept
+func (h *BuildHistory) Get(id int) (BuildRecord, bool) {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+
+    for _, r := range h.records {
+        if r.ID == id {
+            return r, true
+        }
+    }
+    return BuildRecord{}, false
+}
+
+// Subscribe returns a channel receiving build events. It is closed when
+// the subscriber falls behind, Unsubscribe is called or the history is
+// closed.
+func (h *BuildHistory) Subscribe() chan BuildEvent {
+    ch := make(chan BuildEvent, eventQueueSize)
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if h.closed {
+        close(ch)
+        return ch
+    }
+    h.subscribers[ch] = struct{}{}
+    return ch
+}
+
+// Unsubscribe stops delivering events to ch
+func (h *BuildHistory) Unsubscribe(ch chan BuildEvent) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if _, ok := h.subscribers[ch]; ok {
+        delete(h.subscribers, ch)
+        close(ch)
+    }
+}
+
+// Close ends all subscriptions, which ends the event streams
+func (h *BuildHistory) Close() {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    h.closed = true
+    for ch := range h.subscribers {
+        delete(h.subscribers, ch)
+        close(ch)
+    }
+}
+
+// Publish sends an event to all subscribers, dropping those whose queue
+// is full
+func (h *BuildHistory) Publish(event BuildEvent) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    for ch := range h.subscribers {
+        select {
+        case ch <- event:
+        default:
+            log.Println("Build event subscriber is not keeping up, dropping it")
+            delete(h.subscribers, ch)
+            close(ch)
+        }
+    }
+}
+
+// serveBuilds lists the build history, newest first. ?limit=n returns the
+// n most recent builds.
+func (s *Server) serveBuilds(w http.ResponseWriter, r *http.Request) {
+    limit := 0
+    if v := r.URL.Query().Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 {
+            http.Error(w, "Invalid limit", http.StatusBadRequest)
+            return
+        }
+        limit = n
+    }
+    writeJSON(w, s.history.List(limit))
+}
+
+// serveBuild returns one build of the history by ID
+func (s *Server) serveBuild(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        http.Error(w, "Invalid build ID", http.StatusBadRequest)
+        return
+    }
+    record, ok := s.history.Get(id)
+    if !ok {
+        http.Error(w, "Unknown build", http.StatusNotFound)
+        return
+    }
+    writeJSON(w, record)
+}
+
+// serveBuildEvents streams build events as Server-Sent Events:
+//
+//	event: state
+//	data: {"state":"running"}
+//
+//	event: build
+//	data: {"id":12,"status":"succeeded",...}
+//
+// so that editors and scripts can follow builds without a WebSocket client.
+func (s *Server) serveBuildEvents(w http.ResponseWriter, r *http.Request) {
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+
+    events := s.history.Subscribe()
+    defer s.history.Unsubscribe(events)
+
+    // Start with the current state so that subscribers need no extra request
+    writeEvent(w, BuildEvent{Type: "state", Data: map[string]build.BuildState{"state": s.builds.State()}})
+    flusher.Flush()
+
+    keepAlive := time.NewTicker(eventKeepAlive)
+    defer keepAlive.Stop()
+
+    for {
+        select {
+        case <-r.Context().Done():
+            return
+        case event, ok := <-events:
+            if !ok {
+                return
+            }
+            if err := writeEvent(w, event); err != nil {
+                return
+            }
+            flusher.Flush()
+        case <-keepAlive.C:
+            if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+                return
+            }
+            flusher.Flush()
+        }
+    }
+}
+
+// writeEvent writes one Server-Sent Event
+func writeEvent(w http.ResponseWriter, event BuildEvent) error {
+    data, err := json.Marshal(event.Data)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+    return err
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(v); err != nil {
+        http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+    }
+}
@@ -2,22 +2,41 @@
 package main
 
 import (
-    "bytes"
+    "context"
     "encoding/json"
+    "errors"
     "flag"
     "fmt"
     "log"
     "net/http"
+    "net/http/pprof"
     "os"
-    "os/exec"
+    "os/signal"
+    "path"
     "path/filepath"
     "strings"
     "sync"
+    "syscall"
     "time"
     
     "github.com/mmcnicol/go-app-component-library/cmd/dev-server/build"
     "github.com/mmcnicol/go-app-component-library/cmd/dev-server/handlers"
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/static"
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/ui"
     "github.com/mmcnicol/go-app-component-library/cmd/dev-server/watch"
+    "github.com/mmcnicol/go-app-component-library/pkg/wasmserve"
+)
+
+const (
+    // dashboardPath is where the development dashboard is served
+    dashboardPath = "/__dev/"
+    
+    // liveReloadPath is where the live reload client is served
+    liveReloadPath = "/__dev/live-reload.js"
+    
+    // shutdownTimeout bounds how long in-flight requests may take once
+    // the server is asked to stop
+    shutdownTimeout = 10 * time.Second
 )
 
 // DashboardData holds metrics for the development dashboard
@@ -26,8 +45,17 @@ type DashboardData struct {
     LastBuildTime     time.Time `json:"last_build_time"`
     BuildCount        int       `json:"build_count"`
     ConnectedClients  int       `json:"connected_clients"`
+    Clients           []handlers.ClientInfo `json:"clients"`
     FileChanges       []string  `json:"file_changes"`
     CompileErrors     []string  `json:"compile_errors"`
+    Diagnostics       []build.Diagnostic `json:"diagnostics"`
+    LastBuildMs       int64     `json:"last_build_ms"`
+    BuildsRun         int       `json:"builds_run"`
+    CacheHits         int       `json:"cache_hits"`
+    CacheEntries      int       `json:"cache_entries"`
+    Profiles          []build.BuildProfile `json:"profiles,omitempty"`
+    Target            string    `json:"target"`
+    Targets           []build.Target `json:"targets"`
     mu                sync.RWMutex
 }
 
@@ -45,6 +73,13 @@ func (d *DashboardData) AddError(err string) {
     d.CompileErrors = append(d.CompileErrors, err)
 }
 
+// SetDiagnostics records the diagnostics of the last failed build
+func (d *DashboardData) SetDiagnostics(diagnostics []build.Diagnostic) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.Diagnostics = diagnostics
+}
+
 // SetBuildStatus sets the build status
 func (d *DashboardData) SetBuildStatus(status string) {
     d.mu.Lock()
@@ -60,11 +95,44 @@ func (d *DashboardData) SetLastBuildTime(t time.Time) {
     d.BuildCount++
 }
 
-// SetConnectedClients sets the number of connected clients
-func (d *DashboardData) SetConnectedClients(count int) {
+// SetClients records the connected clients
+func (d *DashboardData) SetClients(clients []handlers.ClientInfo) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.ConnectedClients = len(clients)
+    d.Clients = clients
+}
+
+// SetBuildStats copies the compiler's build and cache metrics
+func (d *DashboardData) SetBuildStats(stats build.BuildStats, cacheEntries int) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.LastBuildMs = stats.LastDuration.Milliseconds()
+    d.BuildsRun = stats.Builds
+    d.CacheHits = stats.CacheHits
+    d.CacheEntries = cacheEntries
+}
+
+// SetProfiles records the build profiles collected in profiling mode
+func (d *DashboardData) SetProfiles(profiles []build.BuildProfile) {
     d.mu.Lock()
     defer d.mu.Unlock()
-    d.ConnectedClients = count
+    d.Profiles = profiles
+}
+
+// SetTargets records the build targets and the active one
+func (d *DashboardData) SetTargets(targets []build.Target, active string) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.Targets = targets
+    d.Target = active
+}
+
+// SetTarget records the active build target
+func (d *DashboardData) SetTarget(name string) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.Target = name
 }
 
 // Clear clears all dashboard data
@@ -73,6 +141,7 @@ func (d *DashboardData) Clear() {
     defer d.mu.Unlock()
     d.FileChanges = nil
     d.CompileErrors = nil
+    d.Diagnostics = nil
 }
 
 // GetData returns a copy of the dashboard data
@@ -84,8 +153,17 @@ func (d *DashboardData) GetData() DashboardData {
         LastBuildTime:    d.LastBuildTime,
         BuildCount:       d.BuildCount,
         ConnectedClients: d.ConnectedClients,
+        Clients:          append([]handlers.ClientInfo{}, d.Clients...),
         FileChanges:      append([]string{}, d.FileChanges...),
         CompileErrors:    append([]string{}, d.CompileErrors...),
+        Diagnostics:      append([]build.Diagnostic{}, d.Diagnostics...),
+        LastBuildMs:      d.LastBuildMs,
+        BuildsRun:        d.BuildsRun,
+        CacheHits:        d.CacheHits,
+        CacheEntries:     d.CacheEntries,
+        Profiles:         append([]build.BuildProfile(nil), d.Profiles...),
+        Target:           d.Target,
+        Targets:          append([]build.Target(nil), d.Targets...),
     }
 }
 
@@ -93,175 +171,238 @@ func (d *DashboardData) GetData() DashboardData {
 type Server struct {
     port          int
     workDir       string
-    outputDir     string
-    compiler      *build.Compiler
-    watcher       *watch.Watcher
+    targets       []*build.Compiler // one per build target, in config order
+    active        *build.Compiler   // target served at /app.wasm
+    builds        *build.Queue
+    watcher       watch.Watcher
     liveReload    *handlers.LiveReloadServer
     currentWasm   string
-    wasmMu        sync.RWMutex
+    wasmMu        sync.RWMutex // guards active and currentWasm
+    wasmFiles     *wasmserve.Server
     dashboardData *DashboardData
+    history       *BuildHistory
+    buildError    map[string]interface{} // overlay of the last failed build, nil after a success
+    buildErrorMu  sync.Mutex
+    stop          chan struct{} // closed on shutdown, stops cleanupBuilds
     enableDashboard bool
     profile       bool
+    editorURL     string // template for links in the error overlay
 }
 
 // NewServer creates a new development server
-func NewServer(port int, workDir string, enableDashboard, profile bool) (*Server, error) {
-    // Use web folder as output directory
-    outputDir := filepath.Join(workDir, "web")
-    if err := os.MkdirAll(outputDir, 0755); err != nil {
-        return nil, fmt.Errorf("failed to create web directory: %v", err)
+func NewServer(cfg Config) (*Server, error) {
+    workDir := cfg.Dir
+    
+    // Every target gets its own compiler, output directory and cache
+    targets := cfg.buildTargets()
+    if err := build.ValidateTargets(targets); err != nil {
+        return nil, err
     }
     
-    // Create compiler with error handling
-    compiler, err := build.NewCompiler(workDir, outputDir)
-    if err != nil {
-        // Log the error but continue - the compiler might still work
-        log.Printf("Warning: failed to create compiler: %v", err)
-        // Don't return error here, we'll try to build directly
+    var compilers []*build.Compiler
+    var active *build.Compiler
+    for _, target := range targets {
+        compiler, err := build.NewCompiler(workDir, target)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create compiler for target %s: %v", target.Name, err)
+        }
+        compiler.SetProfiling(cfg.Profile)
+        compilers = append(compilers, compiler)
+        
+        if target.Name == cfg.Target {
+            active = compiler
+        }
+    }
+    if active == nil {
+        if cfg.Target != "" {
+            return nil, fmt.Errorf("unknown build target %q", cfg.Target)
+        }
+        active = compilers[0]
     }
     
     s := &Server{
-        port:            port,
+        port:            cfg.Port,
         workDir:         workDir,
-        outputDir:       outputDir,
-        compiler:        compiler,
+        targets:         compilers,
+        active:          active,
         liveReload:      handlers.NewLiveReloadServer(),
+        wasmFiles:       wasmserve.New(false),
         dashboardData:   &DashboardData{},
-        enableDashboard: enableDashboard,
-        profile:         profile,
+        history:         NewBuildHistory(),
+        stop:            make(chan struct{}),
+        enableDashboard: cfg.Dashboard,
+        profile:         cfg.Profile,
+        editorURL:       cfg.Editor,
     }
     
+    // Keep the dashboard's client list current
+    s.liveReload.OnClientsChange = s.onClientsChange
+    s.liveReload.OnRegister = s.onClientRegister
+    s.liveReload.OnMessage = s.onClientMessage
+    
+    s.dashboardData.SetTargets(targets, active.Target().Name)
+    
+    // All builds go through one queue so that only one go build runs at a
+    // time; only the active target is built
+    s.builds = build.NewQueue(active, s.onBuildState, s.onBuildDone)
+    
     // Initialize watcher - also watch web folder for CSS/JS changes
-    watcher, err := watch.NewWatcher(workDir, s.onFileChange)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create watcher: %v", err)
+    if cfg.Watch.Enabled {
+        opts := cfg.Watch.Options()
+        opts.Exclude = append(opts.Exclude, s.generatedPaths()...)
+        
+        watcher, err := watch.NewWatcher(workDir, opts, s.onFileChange)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create watcher: %v", err)
+        }
+        s.watcher = watcher
     }
-    s.watcher = watcher
     
-    // Initial build - output to web/app.wasm
-    initialWasm, err := s.buildWasm()
-    if err != nil {
-        log.Printf("Initial build warning: %v", err)
-        // Don't fail here - the server can still start
-        // Create a dummy wasm file or continue without it
-        s.currentWasm = ""
-    } else {
-        s.currentWasm = initialWasm
-    }
+    // Initial build - output to web/app-*.wasm
+    // A failed build is logged; the server can still start
+    s.builds.Submit(build.Request{Trigger: "initial build"})
+    s.builds.Wait(context.Background())
     
-    // Start monitoring connected clients
-    go s.monitorClients()
+    // Remove superseded app-*.wasm artifacts
+    go s.cleanupBuilds()
     
     return s, nil
 }
 
-// buildWasm builds the WebAssembly binary
-func (s *Server) buildWasm() (string, error) {
-    outputPath := filepath.Join(s.outputDir, "app.wasm")
-    
-    // The wasm entry point is at cmd/wasm/main.go
-    mainPackage := "./cmd/wasm"
-    
-    // First verify the package exists
-    verifyCmd := exec.Command("go", "list", mainPackage)
-    verifyCmd.Dir = s.workDir
-    if output, err := verifyCmd.CombinedOutput(); err != nil {
-        log.Printf("Package verification failed: %s", output)
-        // Continue anyway, the build might still work
+// generatedPaths returns watch exclusions for the files the server writes
+// itself, so that build output never triggers another build
+func (s *Server) generatedPaths() []string {
+    root, err := filepath.Abs(s.workDir)
+    if err != nil {
+        return nil
     }
     
-    cmd := exec.Command("go", "build",
-        "-o", outputPath,
-        "-tags", "dev",
-        "-ldflags", "-s -w",
-        mainPackage,
-    )
-    
-    cmd.Dir = s.workDir
-    
-    // Set environment variables
-    env := os.Environ()
-    var cleanEnv []string
-    
-    // Filter and set environment
-    for _, e := range env {
-        // Keep most env vars but override some
-        if !strings.HasPrefix(e, "GOOS=") &&
-           !strings.HasPrefix(e, "GOARCH=") &&
-           !strings.HasPrefix(e, "GO111MODULE=") &&
-           !strings.HasPrefix(e, "GOPROXY=") {
-            cleanEnv = append(cleanEnv, e)
+    relative := func(dir string) (string, bool) {
+        abs, err := filepath.Abs(dir)
+        if err != nil {
+            return "", false
+        }
+        rel, err := filepath.Rel(root, abs)
+        if err != nil || strings.HasPrefix(rel, "..") {
+            return "", false
         }
+        return filepath.ToSlash(rel), true
     }
     
-    // Add required env vars for wasm build
-    cleanEnv = append(cleanEnv,
-        "GOOS=js",
-        "GOARCH=wasm",
-        "GO111MODULE=on",
-        "GOPROXY=direct",
-    )
-    
-    cmd.Env = cleanEnv
+    var patterns []string
+    for _, compiler := range s.targets {
+        // The output folder may also hold sources (index.html, styles);
+        // only exclude the wasm artifacts
+        if rel, ok := relative(compiler.OutputDir()); ok {
+            patterns = append(patterns, path.Join(rel, "app.wasm"), path.Join(rel, "app-*.wasm*"))
+        }
+        if rel, ok := relative(compiler.Cache().GetCacheDir()); ok {
+            patterns = append(patterns, rel)
+        }
+    }
+    return patterns
+}
+
+// activeCompiler returns the compiler of the active build target
+func (s *Server) activeCompiler() *build.Compiler {
+    s.wasmMu.RLock()
+    defer s.wasmMu.RUnlock()
+    return s.active
+}
+
+// switchTarget makes the named target the one served at /app.wasm. The
+// target is built (usually from the cache) and clients reload once it is
+// ready.
+func (s *Server) switchTarget(name string) error {
+    var next *build.Compiler
+    for _, compiler := range s.targets {
+        if compiler.Target().Name == name {
+            next = compiler
+        }
+    }
+    if next == nil {
+        return fmt.Errorf("unknown build target %q", name)
+    }
     
-    var stdout, stderr bytes.Buffer
-    cmd.Stdout = &stdout
-    cmd.Stderr = &stderr
+    s.wasmMu.Lock()
+    if s.active == next {
+        s.wasmMu.Unlock()
+        return nil
+    }
+    s.active = next
+    s.currentWasm = ""
+    s.wasmMu.Unlock()
     
-    log.Printf("Building WebAssembly from %s (package: %s)", s.workDir, mainPackage)
-    log.Printf("Command: go build -o %s -tags dev -ldflags \"-s -w\" %s", outputPath, mainPackage)
+    log.Printf("Switching to build target %s (%s)", name, next.Target().Package)
+    s.dashboardData.SetTarget(name)
+    s.builds.SetCompiler(next)
+    s.builds.Submit(build.Request{Trigger: "switched to target " + name})
+    return nil
+}
+
+// updateBuildStats publishes the compiler metrics to the dashboard
+func (s *Server) updateBuildStats() {
+    compiler := s.activeCompiler()
+    s.dashboardData.SetBuildStats(compiler.Stats(), compiler.Cache().GetEntryCount())
+    if s.profile {
+        s.dashboardData.SetProfiles(compiler.Profiles())
+    }
+}
+
+// cleanupBuilds periodically removes old build artifacts from the output directory
+func (s *Server) cleanupBuilds() {
+    ticker := time.NewTicker(time.Minute)
+    defer ticker.Stop()
     
-    if err := cmd.Run(); err != nil {
-        // Try an alternative approach if the first fails
-        log.Printf("Build failed: %v", err)
-        log.Printf("Stderr: %s", stderr.String())
-        log.Printf("Stdout: %s", stdout.String())
+    for {
+        select {
+        case <-s.stop:
+            return
+        case <-ticker.C:
+        }
         
-        return s.buildWasmAlternative(outputPath)
+        for _, compiler := range s.targets {
+            if err := compiler.Cleanup(5 * time.Minute); err != nil {
+                log.Printf("Build cleanup failed: %v", err)
+            }
+        }
     }
-    
-    log.Printf("✅ Built: %s", outputPath)
-    return outputPath, nil
 }
 
-func (s *Server) buildWasmAlternative(outputPath string) (string, error) {
-    // Try building with the full path to main.go
-    mainFile := filepath.Join(s.workDir, "cmd", "wasm", "main.go")
-    
-    log.Printf("Trying alternative build with main file: %s", mainFile)
-    
-    cmd := exec.Command("go", "build",
-        "-o", outputPath,
-        "-tags", "dev",
-        "-ldflags", "-s -w",
-        mainFile,
-    )
-    
-    cmd.Dir = s.workDir
-    cmd.Env = append(os.Environ(),
-        "GOOS=js",
-        "GOARCH=wasm",
-        "GO111MODULE=on",
-    )
+// onClientsChange publishes the connected clients to the dashboard
+func (s *Server) onClientsChange() {
+    s.dashboardData.SetClients(s.liveReload.Clients())
+    s.publishDashboard()
+}
+
+// onClientRegister shows the error overlay to pages opened while the
+// tree does not build
+func (s *Server) onClientRegister(info handlers.ClientInfo) {
+    if info.Kind != "page" {
+        return
+    }
     
-    var stderr bytes.Buffer
-    cmd.Stderr = &stderr
+    s.buildErrorMu.Lock()
+    message := s.buildError
+    s.buildErrorMu.Unlock()
     
-    if err := cmd.Run(); err != nil {
-        return "", fmt.Errorf("alternative build failed: %v\n%s", err, stderr.String())
+    if message != nil {
+        s.liveReload.SendTo(info.ID, "build-error", message)
     }
-    
-    return outputPath, nil
 }
 
-// monitorClients periodically updates the connected client count
-func (s *Server) monitorClients() {
-    ticker := time.NewTicker(2 * time.Second)
-    defer ticker.Stop()
-    
-    for range ticker.C {
-        count := s.liveReload.GetClientCount()
-        s.dashboardData.SetConnectedClients(count)
+// onClientMessage handles messages sent by browsers and the dashboard
+func (s *Server) onClientMessage(from handlers.ClientInfo, msg handlers.Message) {
+    switch msg.Type {
+    case "select-story":
+        // Relay story selections so that synced storybooks follow each other
+        n := s.liveReload.SendWhere(func(c handlers.ClientInfo) bool {
+            return c.ID != from.ID && c.Kind == "page"
+        }, "select-story", msg.Data)
+        log.Printf("Relayed story selection from %s %s to %d clients", from.Kind, from.ID, n)
+        
+    default:
+        log.Printf("Ignoring %q message from client %s", msg.Type, from.ID)
     }
 }
 
@@ -270,98 +411,269 @@ func (s *Server) onFileChange(changedFiles []string) {
     log.Printf("Files changed: %v", changedFiles)
     
     s.dashboardData.AddFileChanges(changedFiles)
-    s.dashboardData.SetBuildStatus("building")
+    s.publishDashboard()
     
-    // Filter only Go files
-    var goFiles []string
+    // Go files go to the compiler; stylesheets are swapped in place
+    var goFiles, cssFiles []string
     for _, file := range changedFiles {
-        if strings.HasSuffix(file, ".go") {
+        switch filepath.Ext(file) {
+        case ".go":
             goFiles = append(goFiles, file)
+        case ".css":
+            cssFiles = append(cssFiles, file)
         }
     }
     
+    if len(cssFiles) > 0 {
+        s.broadcastCSSUpdate(cssFiles)
+    }
+    
     if len(goFiles) == 0 {
         log.Println("No Go files changed, skipping rebuild")
         return
     }
     
-    // Rebuild the wasm
-    wasmPath, err := s.buildWasm()
-    if err != nil {
-        log.Printf("Build failed: %v", err)
-        s.dashboardData.AddError(err.Error())
-        s.dashboardData.SetBuildStatus("error")
-    } else {
+    // Rebuild the wasm if any changed file is part of the build
+    s.builds.Submit(build.Request{Trigger: "file change", Files: goFiles})
+}
+
+// broadcastCSSUpdate tells clients which stylesheets changed so they can
+// swap them without reloading the page. Paths are sent as URL paths
+// relative to the web folder, which is served at the root.
+func (s *Server) broadcastCSSUpdate(files []string) {
+    webDir, _ := filepath.Abs(filepath.Join(s.workDir, "web"))
+    
+    var paths []string
+    for _, file := range files {
+        abs, err := filepath.Abs(file)
+        if err != nil {
+            continue
+        }
+        rel, err := filepath.Rel(webDir, abs)
+        if err != nil || strings.HasPrefix(rel, "..") {
+            // Not served by us; clients fall back to refreshing all stylesheets
+            rel = filepath.Base(abs)
+        }
+        paths = append(paths, "/"+filepath.ToSlash(rel))
+    }
+    
+    log.Printf("Stylesheets changed, hot-swapping: %v", paths)
+    s.liveReload.BroadcastMessage("css-update", map[string]interface{}{
+        "files":     paths,
+        "timestamp": time.Now().Unix(),
+    })
+}
+
+// forceRebuild forces a complete rebuild
+func (s *Server) forceRebuild() {
+    s.builds.Submit(build.Request{Trigger: "manual rebuild", Force: true})
+}
+
+// onBuildState mirrors the build queue state on the dashboard and the
+// build event stream
+func (s *Server) onBuildState(state build.BuildState) {
+    s.dashboardData.SetBuildStatus(string(state))
+    s.publishDashboard()
+    s.history.Publish(BuildEvent{Type: "state", Data: map[string]build.BuildState{"state": state}})
+}
+
+// recordBuild adds a finished build to the build history
+func (s *Server) recordBuild(result build.Result) {
+    record := BuildRecord{
+        Target:     result.Target,
+        Trigger:    result.Trigger,
+        Files:      result.Files,
+        Started:    time.Now().Add(-result.Duration),
+        DurationMs: result.Duration.Milliseconds(),
+        Status:     result.State,
+        Output:     result.OutputPath,
+    }
+    
+    if result.Err != nil {
+        record.Error, record.Diagnostics = s.buildErrorDetails(result.Err)
+    }
+    if result.State == build.StateSucceeded {
+        for _, compiler := range s.targets {
+            if compiler.Target().Name == result.Target {
+                record.CacheHit = compiler.Stats().LastCacheHit
+            }
+        }
+        if info, err := os.Stat(result.OutputPath); err == nil {
+            record.Size = info.Size()
+        }
+    }
+    
+    s.history.Add(record)
+}
+
+// onBuildDone publishes a finished build and notifies clients
+func (s *Server) onBuildDone(result build.Result) {
+    s.recordBuild(result)
+    s.updateBuildStats()
+    defer s.publishDashboard()
+    
+    switch result.State {
+    case build.StateFailed:
+        log.Printf("Build failed (%s): %v", result.Trigger, result.Err)
+        s.dashboardData.AddError(result.Err.Error())
+        s.reportBuildError(result)
+        
+    case build.StateSkipped:
+        log.Println("Changed files are not part of the wasm build, skipping rebuild")
+        
+    case build.StateSucceeded:
         s.wasmMu.Lock()
-        s.currentWasm = wasmPath
+        stale := result.Target != s.active.Target().Name
+        if !stale {
+            s.currentWasm = result.OutputPath
+        }
         s.wasmMu.Unlock()
         
-        s.dashboardData.SetBuildStatus("success")
+        // Finished just as another target was selected
+        if stale {
+            log.Printf("Built inactive target %s, not reloading clients", result.Target)
+            return
+        }
+        
         s.dashboardData.SetLastBuildTime(time.Now())
         
         // Clear errors on successful build
         s.dashboardData.Clear()
-        
-        // Force browser cache invalidation with timestamp
-        timestamp := time.Now().Unix()
+        s.buildErrorMu.Lock()
+        s.buildError = nil
+        s.buildErrorMu.Unlock()
         
         // Notify clients to reload with cache busting
         s.liveReload.BroadcastMessage("reload", map[string]interface{}{
-            "reason":    "file change",
-            "timestamp": timestamp,
-            "files":     goFiles,
+            "reason":    result.Trigger,
+            "timestamp": time.Now().Unix(),
+            "files":     result.Files,
         })
         
-        log.Printf("Rebuilt successfully, notifying clients")
+        log.Printf("Built %s in %v, notifying clients", filepath.Base(result.OutputPath), result.Duration)
     }
 }
 
-// forceRebuild forces a complete rebuild
-func (s *Server) forceRebuild() {
-    s.dashboardData.SetBuildStatus("building")
+// reportBuildError sends the diagnostics of a failed build to the browser,
+// which shows them in an overlay until the next successful build. Pages
+// that register before then get the overlay too.
+func (s *Server) reportBuildError(result build.Result) {
+    output, diagnostics := s.buildErrorDetails(result.Err)
     
-    wasmPath, err := s.buildWasm()
-    if err != nil {
-        log.Printf("Force rebuild failed: %v", err)
-        s.dashboardData.AddError(err.Error())
-        s.dashboardData.SetBuildStatus("error")
-    } else {
-        s.wasmMu.Lock()
-        s.currentWasm = wasmPath
-        s.wasmMu.Unlock()
-        
-        s.dashboardData.SetBuildStatus("success")
-        s.dashboardData.SetLastBuildTime(time.Now())
-        s.dashboardData.Clear()
-        
-        s.liveReload.BroadcastReload("manual rebuild")
+    message := map[string]interface{}{
+        "trigger":     result.Trigger,
+        "timestamp":   time.Now().Unix(),
+        "output":      output,
+        "diagnostics": diagnostics,
+    }
+    s.buildErrorMu.Lock()
+    s.buildError = message
+    s.buildErrorMu.Unlock()
+    
+    s.dashboardData.SetDiagnostics(diagnostics)
+    s.liveReload.BroadcastMessage("build-error", message)
+}
+
+// buildErrorDetails returns the compiler output of a failed build and its
+// diagnostics with editor links
+func (s *Server) buildErrorDetails(err error) (string, []build.Diagnostic) {
+    var buildErr *build.BuildError
+    if !errors.As(err, &buildErr) {
+        return err.Error(), nil
+    }
+    
+    var diagnostics []build.Diagnostic
+    for _, d := range buildErr.Diagnostics {
+        d.Link = editorLink(s.editorURL, d)
+        diagnostics = append(diagnostics, d)
+    }
+    return buildErr.Output, diagnostics
+}
+
+// editorLink expands the {file}, {line} and {column} placeholders of an
+// editor URL template, e.g. "vscode://file/{file}:{line}:{column}"
+func editorLink(template string, d build.Diagnostic) string {
+    if template == "" {
+        return ""
+    }
+    column := d.Column
+    if column == 0 {
+        column = 1
     }
+    return strings.NewReplacer(
+        "{file}", filepath.ToSlash(d.File),
+        "{line}", fmt.Sprint(d.Line),
+        "{column}", fmt.Sprint(column),
+    ).Replace(template)
 }
 
-// clearCache clears the build cache
+// clearCache clears the build caches of all targets
 func (s *Server) clearCache() {
-    // Implementation depends on your cache structure
-    log.Println("Cache cleared (placeholder)")
+    for _, compiler := range s.targets {
+        if err := compiler.Cache().Clear(); err != nil {
+            log.Printf("Failed to clear cache: %v", err)
+            s.dashboardData.AddError(err.Error())
+            return
+        }
+    }
+    s.updateBuildStats()
+    log.Println("Cache cleared")
     s.dashboardData.AddFileChanges([]string{"Cache cleared manually"})
+    s.publishDashboard()
+}
+
+// publishDashboard pushes the dashboard data to connected dashboards
+func (s *Server) publishDashboard() {
+    if !s.enableDashboard {
+        return
+    }
+    s.updateBuildStats()
+    data := s.dashboardData.GetData()
+    s.liveReload.SendWhere(isDashboard, "dashboard", &data)
 }
 
-// Start starts the development server
-func (s *Server) Start() error {
+// Start serves until ctx is canceled, then shuts the server down
+func (s *Server) Start(ctx context.Context) error {
     mux := http.NewServeMux()
     
     // Serve static files from web folder
     webDir := filepath.Join(s.workDir, "web")
-    mux.Handle("/", http.FileServer(http.Dir(webDir)))
+    // Serve static files from web folder, with the live reload client
+    // injected into every HTML page
+    mux.Handle("/", handlers.InjectScript(http.FileServer(http.Dir(webDir)), liveReloadPath))
     
     // Override specific routes
     mux.HandleFunc("/app.wasm", s.serveWasm)
+    mux.HandleFunc("/wasm_exec.js", s.serveWasmExec)
     mux.Handle("/ws", s.liveReload)
+    mux.HandleFunc(liveReloadPath, serveLiveReloadScript)
     
-    // Development dashboard API (if enabled)
+    // Build history and events, for editors and scripts
+    mux.HandleFunc("GET /api/builds", s.serveBuilds)
+    mux.HandleFunc("GET /api/builds/{id}", s.serveBuild)
+    mux.HandleFunc("GET /api/events", s.serveBuildEvents)
+    
+    // Development dashboard and its API (if enabled)
     if s.enableDashboard {
+        mux.Handle(dashboardPath, http.StripPrefix(dashboardPath, http.HandlerFunc(s.serveDashboard)))
+        mux.Handle(strings.TrimSuffix(dashboardPath, "/"), http.RedirectHandler(dashboardPath, http.StatusMovedPermanently))
         mux.HandleFunc("/api/dashboard", s.serveDashboardData)
-        mux.HandleFunc("/api/rebuild", s.handleRebuild)
-        mux.HandleFunc("/api/clear-cache", s.handleClearCache)
+        
+        // Endpoints that change state or start builds only serve the
+        // dashboard itself
+        mux.Handle("/api/rebuild", handlers.SameOrigin(http.HandlerFunc(s.handleRebuild)))
+        mux.Handle("/api/clear-cache", handlers.SameOrigin(http.HandlerFunc(s.handleClearCache)))
+        mux.Handle("/api/target", handlers.SameOrigin(http.HandlerFunc(s.handleTarget)))
+        mux.Handle("/api/tinygo-report", handlers.SameOrigin(http.HandlerFunc(s.handleTinyGoReport)))
+    }
+    
+    // Profiling endpoints for the dev server itself
+    if s.profile {
+        mux.HandleFunc("/debug/pprof/", pprof.Index)
+        mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+        mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+        mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+        mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
     }
     
     addr := fmt.Sprintf(":%d", s.port)
@@ -369,47 +681,172 @@ func (s *Server) Start() error {
     log.Printf("Serving from: %s", webDir)
     
     if s.enableDashboard {
-        log.Printf("Dashboard API: http://localhost%s/api/dashboard", addr)
+        log.Printf("Dashboard: http://localhost%s%s", addr, dashboardPath)
+    }
+    log.Printf("Build events: http://localhost%s/api/events", addr)
+    if s.profile {
+        log.Printf("Profiling: http://localhost%s/debug/pprof/", addr)
+    }
+    
+    srv := &http.Server{Addr: addr, Handler: mux}
+    errc := make(chan error, 1)
+    go func() {
+        errc <- srv.ListenAndServe()
+    }()
+    
+    select {
+    case err := <-errc:
+        s.Shutdown(context.Background(), nil)
+        return err
+    case <-ctx.Done():
     }
     
-    return http.ListenAndServe(addr, mux)
+    log.Println("Shutting down...")
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+    return s.Shutdown(shutdownCtx, srv)
 }
 
-// serveWasm serves the WebAssembly binary
+// Shutdown stops the watcher, the build queue and the periodic cleanup,
+// then shuts srv (if any) down. Event streams end and WebSocket clients
+// get a close frame telling them the server is restarting, so that they
+// reconnect. Finally the build caches are written to disk.
+func (s *Server) Shutdown(ctx context.Context, srv *http.Server) error {
+    if s.watcher != nil {
+        if err := s.watcher.Close(); err != nil {
+            log.Printf("Failed to close watcher: %v", err)
+        }
+    }
+    close(s.stop)
+    s.builds.Close()
+    
+    // Event streams are in-flight requests; end them so that srv does not
+    // wait for them
+    s.history.Close()
+    
+    var err error
+    if srv != nil {
+        if err = srv.Shutdown(ctx); err != nil {
+            err = fmt.Errorf("graceful shutdown failed: %v", err)
+        }
+    }
+    
+    // WebSocket connections are hijacked, srv does not track them
+    s.liveReload.CloseAll("server restarting")
+    
+    for _, compiler := range s.targets {
+        if ferr := compiler.Cache().Flush(); ferr != nil {
+            log.Printf("Failed to write build cache of target %s: %v", compiler.Target().Name, ferr)
+        }
+    }
+    return err
+}
+
+// serveWasm serves the WebAssembly binary, compressed on the fly when the
+// browser accepts it. Dev builds are not pre-compressed, that would slow
+// down every rebuild.
 func (s *Server) serveWasm(w http.ResponseWriter, r *http.Request) {
     s.wasmMu.RLock()
     wasmPath := s.currentWasm
     s.wasmMu.RUnlock()
     
     if wasmPath == "" {
-        // Try to build on-demand
-        log.Println("No WebAssembly binary available, attempting to build...")
-        wasmPath, err := s.buildWasm()
-        if err != nil {
-            http.Error(w, "No WebAssembly binary available and build failed: "+err.Error(), http.StatusNotFound)
+        // Try to build on-demand, unless a build (e.g. of a newly selected
+        // target) is already on its way
+        if state := s.builds.State(); state != build.StateQueued && state != build.StateRunning {
+            log.Println("No WebAssembly binary available, attempting to build...")
+            s.builds.Submit(build.Request{Trigger: "on-demand build"})
+        }
+        if err := s.builds.Wait(r.Context()); err != nil {
             return
         }
-        s.wasmMu.Lock()
-        s.currentWasm = wasmPath
-        s.wasmMu.Unlock()
+        
+        s.wasmMu.RLock()
+        wasmPath = s.currentWasm
+        s.wasmMu.RUnlock()
+        
+        if wasmPath == "" {
+            http.Error(w, "No WebAssembly binary available and build failed", http.StatusNotFound)
+            return
+        }
+    }
+    
+    // Not cached: the next build replaces it
+    s.wasmFiles.ServeFile(w, r, wasmPath)
+}
+
+// serveWasmExec serves the runtime shim of the active target's toolchain,
+// falling back to the copy in the web folder
+func (s *Server) serveWasmExec(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/javascript")
+    w.Header().Set("Cache-Control", "no-cache")
+    
+    path, err := s.activeCompiler().WasmExecPath()
+    if err != nil {
+        log.Printf("Serving web/wasm_exec.js: %v", err)
+        path = filepath.Join(s.workDir, "web", "wasm_exec.js")
+    }
+    http.ServeFile(w, r, path)
+}
+
+// isDashboard selects the dashboard pages among the connected clients
+func isDashboard(c handlers.ClientInfo) bool {
+    return c.Kind == "dashboard"
+}
+
+// serveLiveReloadScript serves the embedded live reload client
+func serveLiveReloadScript(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/javascript")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Write(static.LiveReloadJS)
+}
+
+// serveDashboard renders the dashboard page and serves its assets. Paths
+// are relative to dashboardPath.
+func (s *Server) serveDashboard(w http.ResponseWriter, r *http.Request) {
+    if r.URL.Path != "" {
+        ui.Assets().ServeHTTP(w, r)
+        return
+    }
+    
+    s.updateBuildStats()
+    data := s.dashboardData.GetData()
+    
+    lastBuild := ""
+    if !data.LastBuildTime.IsZero() {
+        lastBuild = data.LastBuildTime.Format(time.RFC1123)
     }
     
-    // Add cache busting headers
-    w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-    w.Header().Set("Pragma", "no-cache")
-    w.Header().Set("Expires", "0")
+    page := ui.Page(&ui.DevDashboard{
+        BuildStatus:      data.BuildStatus,
+        LastBuildTime:    lastBuild,
+        LastBuildMs:      data.LastBuildMs,
+        BuildsRun:        data.BuildsRun,
+        CacheHits:        data.CacheHits,
+        CacheEntries:     data.CacheEntries,
+        Profiling:        s.profile,
+        Target:           data.Target,
+        Targets:          targetNames(data.Targets),
+        ConnectedClients: data.ConnectedClients,
+        Clients:          data.Clients,
+        FileChanges:      data.FileChanges,
+        CompileErrors:    data.CompileErrors,
+    }, dashboardPath)
     
-    http.ServeFile(w, r, wasmPath)
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Write(page)
 }
 
 // serveDashboardData serves dashboard data as JSON
 func (s *Server) serveDashboardData(w http.ResponseWriter, r *http.Request) {
+    s.updateBuildStats()
     data := s.dashboardData.GetData()
     
     w.Header().Set("Content-Type", "application/json")
     w.Header().Set("Access-Control-Allow-Origin", "*")
     
-    if err := json.NewEncoder(w).Encode(data); err != nil {
+    if err := json.NewEncoder(w).Encode(&data); err != nil {
         http.Error(w, "Failed to encode dashboard data", http.StatusInternalServerError)
     }
 }
@@ -421,13 +858,74 @@ func (s *Server) handleRebuild(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    go s.forceRebuild()
+    s.forceRebuild()
     
     w.Header().Set("Content-Type", "application/json")
-    w.Header().Set("Access-Control-Allow-Origin", "*")
     json.NewEncoder(w).Encode(map[string]string{"status": "rebuild_started"})
 }
 
+// handleTarget switches the active build target. The request body is
+// {"target": "<name>"}.
+func (s *Server) handleTarget(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    
+    var req struct {
+        Target string `json:"target"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    if err := s.switchTarget(req.Target); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    s.publishDashboard()
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{"status": "target_switched", "target": req.Target})
+}
+
+// handleTinyGoReport compiles the component packages with TinyGo and
+// returns which fail. This takes a while: every package is a separate build.
+func (s *Server) handleTinyGoReport(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    
+    tinygo, tags := tinyGoToolchain(s.targetList())
+    results, err := build.CheckTinyGo(r.Context(), s.workDir, tinygo, tags, componentPackages)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(results)
+}
+
+// targetList returns the configured build targets
+func (s *Server) targetList() []build.Target {
+    targets := make([]build.Target, len(s.targets))
+    for i, compiler := range s.targets {
+        targets[i] = compiler.Target()
+    }
+    return targets
+}
+
+// targetNames returns the names of the build targets
+func targetNames(targets []build.Target) []string {
+    names := make([]string, len(targets))
+    for i, t := range targets {
+        names[i] = t.Name
+    }
+    return names
+}
+
 // handleClearCache handles cache clearing requests
 func (s *Server) handleClearCache(w http.ResponseWriter, r *http.Request) {
     if r.Method != http.MethodPost {
@@ -438,34 +936,115 @@ func (s *Server) handleClearCache(w http.ResponseWriter, r *http.Request) {
     go s.clearCache()
     
     w.Header().Set("Content-Type", "application/json")
-    w.Header().Set("Access-Control-Allow-Origin", "*")
     json.NewEncoder(w).Encode(map[string]string{"status": "cache_cleared"})
 }
 
 // main is the entry point for the dev-server
 func main() {
+    defaults := defaultConfig()
     var (
-        port          = flag.Int("port", 8080, "Port to listen on")
-        workDir       = flag.String("dir", ".", "Working directory")
-        watch         = flag.Bool("watch", true, "Enable file watching")
-        dashboard     = flag.Bool("dashboard", false, "Enable dashboard")
-        profile       = flag.Bool("profile", false, "Enable profiling")
+        configFile    = flag.String("config", defaultConfigFile, "Path to a JSON config file")
+        port          = flag.Int("port", defaults.Port, "Port to listen on")
+        workDir       = flag.String("dir", defaults.Dir, "Working directory")
+        watchEnabled  = flag.Bool("watch", defaults.Watch.Enabled, "Enable file watching")
+        include       = flag.String("watch-include", "", "Comma separated globs of paths to watch (default: everything)")
+        exclude       = flag.String("watch-exclude", "", "Comma separated globs of paths to ignore, in addition to "+strings.Join(watch.DefaultOptions().Exclude, ","))
+        extensions    = flag.String("watch-ext", strings.Join(defaults.Watch.Extensions, ","), "Comma separated file extensions to watch")
+        debounce      = flag.Duration("debounce", time.Duration(defaults.Watch.Debounce), "Wait this long for further changes before rebuilding")
+        backend       = flag.String("watch-backend", defaults.Watch.Backend, "How to detect changes: auto, fsnotify or poll")
+        pollInterval  = flag.Duration("poll-interval", time.Duration(defaults.Watch.PollInterval), "How often the poll backend scans for changes")
+        dashboard     = flag.Bool("dashboard", defaults.Dashboard, "Enable dashboard")
+        profile       = flag.Bool("profile", defaults.Profile, "Enable profiling")
+        editor        = flag.String("editor", defaults.Editor, "Editor URL template for compile error links")
+        target        = flag.String("target", "", "Build target to serve initially (default: the first configured)")
+        tinygoReport  = flag.Bool("tinygo-report", false, "Report which component packages fail to compile with TinyGo, then exit")
     )
     
     flag.Parse()
     
-    server, err := NewServer(*port, *workDir, *dashboard, *profile)
+    // Flags given on the command line override the config file
+    set := make(map[string]bool)
+    flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+    
+    cfg, err := loadConfig(*configFile, set["config"])
+    if err != nil {
+        log.Fatalf("Failed to load config: %v", err)
+    }
+    
+    if set["port"] {
+        cfg.Port = *port
+    }
+    if set["dir"] {
+        cfg.Dir = *workDir
+    }
+    if set["watch"] {
+        cfg.Watch.Enabled = *watchEnabled
+    }
+    if set["watch-include"] {
+        cfg.Watch.Include = splitList(*include)
+    }
+    if set["watch-exclude"] {
+        cfg.Watch.Exclude = splitList(*exclude)
+    }
+    if set["watch-ext"] {
+        cfg.Watch.Extensions = splitList(*extensions)
+    }
+    if set["debounce"] {
+        cfg.Watch.Debounce = Duration(*debounce)
+    }
+    if set["watch-backend"] {
+        cfg.Watch.Backend = *backend
+    }
+    if set["poll-interval"] {
+        cfg.Watch.PollInterval = Duration(*pollInterval)
+    }
+    if set["dashboard"] {
+        cfg.Dashboard = *dashboard
+    }
+    if set["profile"] {
+        cfg.Profile = *profile
+    }
+    if set["editor"] {
+        cfg.Editor = *editor
+    }
+    if set["target"] {
+        cfg.Target = *target
+    }
+    
+    if *tinygoReport {
+        os.Exit(runTinyGoReport(cfg))
+    }
+    
+    server, err := NewServer(cfg)
     if err != nil {
         log.Fatalf("Failed to create server: %v", err)
     }
     
     log.Printf("Starting development server...")
-    log.Printf("  Port: %d", *port)
-    log.Printf("  Directory: %s", *workDir)
-    log.Printf("  Watch: %v", *watch)
-    log.Printf("  Dashboard: %v", *dashboard)
+    log.Printf("  Port: %d", cfg.Port)
+    log.Printf("  Directory: %s", cfg.Dir)
+    log.Printf("  Watch: %v", cfg.Watch.Enabled)
+    if cfg.Watch.Enabled {
+        log.Printf("    Include: %v", cfg.Watch.Include)
+        log.Printf("    Exclude: %v", cfg.Watch.Options().Exclude)
+        log.Printf("    Extensions: %v", cfg.Watch.Extensions)
+        log.Printf("    Debounce: %v", time.Duration(cfg.Watch.Debounce))
+        log.Printf("    Backend: %s", cfg.Watch.Backend)
+    }
+    log.Printf("  Dashboard: %v", cfg.Dashboard)
+    log.Printf("  Targets: %v (active: %s)", targetNames(cfg.buildTargets()), server.activeCompiler().Target().Name)
+    
+    // Shut down cleanly on Ctrl-C or when a process manager stops us
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    go func() {
+        // A second signal kills the process if shutting down hangs
+        <-ctx.Done()
+        stop()
+    }()
     
-    if err := server.Start(); err != nil {
+    if err := server.Start(ctx); err != nil {
         log.Fatalf("Server failed: %v", err)
     }
+    log.Println("Development server stopped")
 }
@@ -1,36 +1,62 @@
-<!-- cmd/dev-server/static/live-reload.js -->
+// cmd/dev-server/static/live-reload.js
 (function() {
-    // Only in development
-    if (window.location.hostname !== 'localhost' && 
-        window.location.hostname !== '127.0.0.1') {
-        return;
-    }
-    
+    // Served and injected by the dev server only
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     const wsUrl = `${protocol}//${window.location.host}/ws`;
     
+    let socket = null;
     let reconnectAttempts = 0;
     let maxReconnectAttempts = 10;
     let reconnectDelay = 1000;
     
     function connect() {
         const ws = new WebSocket(wsUrl);
+        socket = ws;
         
         ws.onopen = function() {
             console.log('🔗 Live reload connected');
             reconnectAttempts = 0;
+            notifyStatus('connected');
+            register();
         };
         
         ws.onmessage = function(event) {
             const message = JSON.parse(event.data);
             
+            if (message.type === 'build-error') {
+                showBuildError(message.data);
+                return;
+            }
+            
+            if (message.type === 'select-story') {
+                // Another browser or the dashboard selected a story; the
+                // storybook Shell follows it when sync is enabled
+                const hooks = window.__storybookDevHooks;
+                if (hooks && typeof hooks.selectStory === 'function') {
+                    hooks.selectStory(message.data);
+                }
+                return;
+            }
+            
+            if (message.type === 'css-update') {
+                updateStylesheets(message.data.files || []);
+                return;
+            }
+            
             if (message.type === 'reload') {
-                console.log(`🔄 Reloading page: ${message.reason}`);
+                hideBuildError();
+                
+                console.log(`🔄 Reloading page: ${message.data?.reason}`);
                 
-                // Try to preserve component state
-                const state = window.app?.state;
-                if (state) {
-                    localStorage.setItem('__dev_state', JSON.stringify(state));
+                // Let the storybook Shell save its state; it restores
+                // it from sessionStorage once the new wasm is mounted
+                const hooks = window.__storybookDevHooks;
+                if (hooks && typeof hooks.saveState === 'function') {
+                    try {
+                        hooks.saveState();
+                    } catch (e) {
+                        console.warn('Failed to save state:', e);
+                    }
                 }
                 
                 // Reload the page
@@ -40,8 +66,17 @@
             }
         };
         
-        ws.onclose = function() {
-            console.log('📡 Live reload disconnected');
+        ws.onclose = function(event) {
+            // 1001 (going away): the dev server is restarting, so start
+            // over with short reconnect delays
+            if (event.code === 1001) {
+                console.log(`📡 Live reload disconnected: ${event.reason || 'server going away'}`);
+                notifyStatus('restarting');
+                reconnectAttempts = 0;
+            } else {
+                console.log('📡 Live reload disconnected');
+                notifyStatus('disconnected');
+            }
             
             if (reconnectAttempts < maxReconnectAttempts) {
                 reconnectAttempts++;
@@ -57,17 +92,130 @@
         };
     }
     
-    // Restore state on load
-    window.addEventListener('load', function() {
-        const savedState = localStorage.getItem('__dev_state');
-        if (savedState && window.app) {
-            try {
-                const state = JSON.parse(savedState);
-                Object.assign(window.app.state, state);
-                localStorage.removeItem('__dev_state');
-            } catch (e) {
-                console.warn('Failed to restore state:', e);
+    // Tell the server which page and story this client shows; the
+    // storybook keeps the selected story in the query string
+    function register() {
+        if (!socket || socket.readyState !== WebSocket.OPEN) {
+            return;
+        }
+        const query = new URLSearchParams(window.location.search);
+        socket.send(JSON.stringify({
+            type: 'register',
+            data: {
+                kind: 'page',
+                url: window.location.href,
+                component: query.get('component') || '',
+                story: query.get('story') || '',
+            },
+        }));
+    }
+    
+    // go-app navigates with the history API; re-register on every change
+    ['pushState', 'replaceState'].forEach(function(name) {
+        const original = history[name];
+        history[name] = function() {
+            const result = original.apply(this, arguments);
+            register();
+            return result;
+        };
+    });
+    window.addEventListener('popstate', register);
+    
+    // Pages can follow the connection state, e.g. to show a banner
+    function notifyStatus(status) {
+        window.dispatchEvent(new CustomEvent('livereload:status', { detail: status }));
+    }
+    
+    // Swap changed stylesheets in place so the page keeps its state.
+    // A changed file that no <link> refers to directly (e.g. one that is
+    // part of a bundle) refreshes every stylesheet instead.
+    function updateStylesheets(files) {
+        const links = Array.from(document.querySelectorAll('link[rel="stylesheet"]'));
+        const stamp = Date.now();
+        
+        function swap(link) {
+            const url = new URL(link.href, window.location.href);
+            url.searchParams.set('__reload', stamp);
+            link.href = url.toString();
+        }
+        
+        let matchedAll = true;
+        files.forEach(function(file) {
+            const matches = links.filter(function(link) {
+                return new URL(link.href, window.location.href).pathname.endsWith(file);
+            });
+            if (matches.length === 0) {
+                matchedAll = false;
             }
+            matches.forEach(swap);
+        });
+        
+        if (!matchedAll) {
+            links.forEach(swap);
+        }
+        
+        console.log('🎨 Stylesheets updated:', files);
+    }
+    
+    // Compile error overlay
+    const overlayId = '__dev_build_error';
+    
+    function escapeHTML(text) {
+        return String(text)
+            .replace(/&/g, '&amp;')
+            .replace(/</g, '&lt;')
+            .replace(/>/g, '&gt;')
+            .replace(/"/g, '&quot;');
+    }
+    
+    function showBuildError(data) {
+        hideBuildError();
+        
+        const diagnostics = data.diagnostics || [];
+        let body;
+        if (diagnostics.length > 0) {
+            body = diagnostics.map(function(d) {
+                const position = `${d.file}:${d.line}${d.column ? ':' + d.column : ''}`;
+                const location = d.link
+                    ? `<a href="${escapeHTML(d.link)}" style="color:#7cc4ff;">${escapeHTML(position)}</a>`
+                    : escapeHTML(position);
+                return `<li style="margin-bottom:12px;">
+                    <div>${location}</div>
+                    <pre style="margin:4px 0 0;white-space:pre-wrap;color:#ffb3b3;">${escapeHTML(d.message)}</pre>
+                </li>`;
+            }).join('');
+            body = `<ul style="list-style:none;padding:0;margin:0;">${body}</ul>`;
+        } else {
+            body = `<pre style="white-space:pre-wrap;color:#ffb3b3;">${escapeHTML(data.output || 'Build failed')}</pre>`;
+        }
+        
+        const overlay = document.createElement('div');
+        overlay.id = overlayId;
+        overlay.style.cssText = 'position:fixed;inset:0;z-index:2147483647;overflow:auto;' +
+            'background:rgba(20,20,20,0.95);color:#eee;padding:32px;' +
+            'font:14px/1.5 Menlo,Consolas,monospace;';
+        overlay.innerHTML = `
+            <button type="button" aria-label="Dismiss" style="position:absolute;top:16px;right:16px;
+                background:none;border:1px solid #888;color:#eee;padding:4px 10px;cursor:pointer;">✕</button>
+            <h2 style="margin:0 0 4px;color:#ff6b6b;">Build failed</h2>
+            <p style="margin:0 0 24px;color:#aaa;">${escapeHTML(data.trigger || '')}</p>
+            ${body}`;
+        overlay.querySelector('button').addEventListener('click', hideBuildError);
+        
+        document.body.appendChild(overlay);
+        console.error('❌ Build failed:\n' + (data.output || ''));
+    }
+    
+    function hideBuildError() {
+        const overlay = document.getElementById(overlayId);
+        if (overlay) {
+            overlay.remove();
+        }
+    }
+    
+    document.addEventListener('keydown', function(event) {
+        if (event.key === 'Escape') {
+            hideBuildError();
         }
     });
     
@@ -77,6 +225,15 @@
     // Export for manual control
     window.liveReload = {
         reconnect: connect,
+        // send delivers a message to the dev server, e.g. a story
+        // selection to share with the other browsers
+        send: function(type, data) {
+            if (!socket || socket.readyState !== WebSocket.OPEN) {
+                return false;
+            }
+            socket.send(JSON.stringify({ type: type, data: data }));
+            return true;
+        },
         disconnect: function() {
             // Implementation for manual disconnection
         }
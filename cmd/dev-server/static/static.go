@@ -0,0 +1,11 @@
+// cmd/dev-server/static/static.go
+
+// Package static holds the browser assets served by the dev server
+package static
+
+import _ "embed"
+
+// LiveReloadJS is the live reload client injected into served HTML pages
+//
+//go:embed live-reload.js
+var LiveReloadJS []byte
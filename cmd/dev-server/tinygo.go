@@ -0,0 +1,88 @@
+// cmd/dev-server/tinygo.go
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/build"
+)
+
+// componentPackages are the packages the TinyGo report covers
+const componentPackages = "./pkg/components/..."
+
+// tinyGoToolchain returns the TinyGo binary and build tags of the first
+// TinyGo target, or plain "tinygo" without tags when none is configured
+func tinyGoToolchain(targets []build.Target) (string, []string) {
+    for _, t := range targets {
+        if t.TinyGo() {
+            return t.GoBinary, t.Tags
+        }
+    }
+    return "tinygo", nil
+}
+
+// runTinyGoReport prints which component packages compile with TinyGo and
+// returns the exit status: 1 when any package fails
+func runTinyGoReport(cfg Config) int {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+
+    workDir, err := filepath.Abs(cfg.Dir)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "TinyGo report failed: %v\n", err)
+        return 2
+    }
+    
+    tinygo, tags := tinyGoToolchain(cfg.buildTargets())
+    fmt.Printf("Compiling %s with %s (tags %v)...\n\n", componentPackages, tinygo, tags)
+
+    results, err := build.CheckTinyGo(ctx, workDir, tinygo, tags, componentPackages)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "TinyGo report failed: %v\n", err)
+        return 2
+    }
+
+    tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(tw, "STATUS\tTIME\tPACKAGE\tFIRST ERROR")
+    failed := 0
+    for _, r := range results {
+        status, firstError := "ok", ""
+        if !r.OK {
+            status = "FAIL"
+            failed++
+            firstError = firstLine(r.Output)
+            if len(r.Diagnostics) > 0 {
+                d := r.Diagnostics[0]
+                file := d.File
+                if rel, err := filepath.Rel(workDir, file); err == nil {
+                    file = rel
+                }
+                firstError = fmt.Sprintf("%s:%d: %s", file, d.Line, d.Message)
+            }
+        }
+        fmt.Fprintf(tw, "%s\t%dms\t%s\t%s\n", status, r.DurationMs, r.Package, firstError)
+    }
+    tw.Flush()
+
+    fmt.Printf("\n%d of %d packages compile with TinyGo\n", len(results)-failed, len(results))
+    if failed > 0 {
+        return 1
+    }
+    return 0
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+    for _, line := range strings.Split(s, "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            return line
+        }
+    }
+    return ""
+}
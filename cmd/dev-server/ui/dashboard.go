@@ -2,72 +2,133 @@
 package ui
 
 import (
+    "fmt"
+
     "github.com/maxence-charriere/go-app/v10/pkg/app"
+    "github.com/mmcnicol/go-app-component-library/cmd/dev-server/handlers"
 )
 
+// DevDashboard is rendered on the server (see Page). Elements carrying a
+// data-field attribute are kept up to date by dashboard.js from the
+// "dashboard" messages pushed over the live reload WebSocket, and the
+// action buttons are wired to the dev server API by their IDs.
 type DevDashboard struct {
     app.Compo
-    
-    buildStatus    string
-    lastBuildTime  string
-    connectedClients int
-    fileChanges    []string
-    compileErrors  []string
+
+    BuildStatus      string
+    LastBuildTime    string
+    LastBuildMs      int64
+    BuildsRun        int
+    CacheHits        int
+    CacheEntries     int
+    ConnectedClients int
+    Clients          []handlers.ClientInfo
+    FileChanges      []string
+    CompileErrors    []string
+    Profiling        bool // show build profile charts
+    Target           string
+    Targets          []string
 }
 
 func (d *DevDashboard) Render() app.UI {
 	return app.Div().Class("dev-dashboard").Body(
 		app.Header().Class("dashboard-header").Body(
 			app.H1().Text("Go UI Library Dev Server"),
-			// Fixed: added getStatusClass() logic below
-			app.Div().Class("status-indicator").Class(d.getStatusClass()).Body(
-				app.Span().Text(d.buildStatus),
-			),
+			app.Div().
+				ID("build-status").
+				Class("status-indicator").
+				Class(d.getStatusClass()).
+				Body(
+					app.Span().DataSet("field", "build_status").Text(d.BuildStatus),
+				),
 		),
 
 		app.Main().Class("dashboard-main").Body(
 			app.Section().Class("build-info").Body(
 				app.H2().Text("Build Information"),
 				app.Ul().Body(
-					app.Li().Body(
-						app.Strong().Text("Last build: "),
-						app.Span().Text(d.lastBuildTime),
+					d.renderInfo("Target: ", "target", d.Target),
+					d.renderInfo("Last build: ", "last_build_time", d.LastBuildTime),
+					d.renderInfo("Last build duration (ms): ", "last_build_ms", d.LastBuildMs),
+					d.renderInfo("Builds run: ", "builds_run", d.BuildsRun),
+					d.renderInfo("Cache hits: ", "cache_hits", d.CacheHits),
+					d.renderInfo("Cache entries: ", "cache_entries", d.CacheEntries),
+					d.renderInfo("Connected clients: ", "connected_clients", d.ConnectedClients),
+				),
+			),
+
+			app.If(len(d.Targets) > 1, func() app.UI {
+				return app.Section().Class("targets").Body(
+					app.H2().Text("Build Target"),
+					app.Div().ID("targets").Body(
+						app.Range(d.Targets).Slice(func(i int) app.UI {
+							return d.renderTargetButton(d.Targets[i])
+						}),
+					),
+				)
+			}),
+
+			app.If(d.Profiling, func() app.UI {
+				return app.Section().Class("profile").Body(
+					app.H2().Text("Build Profile"),
+					app.H3().Text("Build phases (ms)"),
+					app.Div().ID("phase-chart").Class("chart").Body(
+						app.P().Text("Waiting for builds..."),
 					),
-					app.Li().Body(
-						app.Strong().Text("Connected clients: "),
-						app.Span().Text(d.connectedClients),
+					app.H3().Text("WASM size"),
+					app.Div().ID("size-chart").Class("chart").Body(
+						app.P().Text("Waiting for builds..."),
 					),
+				)
+			}),
+
+			app.Section().Class("clients").Body(
+				app.H2().Text("Connected Clients"),
+				app.Div().ID("clients").Body(
+					d.renderClients(),
 				),
 			),
 
 			app.Section().Class("file-changes").Body(
 				app.H2().Text("Recent Changes"),
-				d.renderFileChanges(), // Fixed: added implementation below
+				app.Div().ID("file-changes").Body(
+					d.renderFileChanges(),
+				),
 			),
 
 			app.Section().Class("errors").Body(
 				app.H2().Text("Errors"),
-				d.renderErrors(), // Fixed: added implementation below
+				app.Div().ID("compile-errors").Body(
+					d.renderErrors(),
+				),
 			),
 
 			app.Section().Class("actions").Body(
 				app.H2().Text("Actions"),
 				app.Button().
+					ID("force-rebuild").
 					Class("btn btn-primary").
-					Text("Force Rebuild").
-					OnClick(d.onForceRebuild), // Fixed: added handler below
+					Text("Force Rebuild"),
 				app.Button().
+					ID("clear-cache").
 					Class("btn btn-secondary").
-					Text("Clear Cache").
-					OnClick(d.onClearCache), // Fixed: added handler below
+					Text("Clear Cache"),
+				app.Span().ID("action-result").Class("action-result"),
 			),
 		),
 	)
 }
 
+func (d *DevDashboard) renderInfo(label, field string, value any) app.UI {
+	return app.Li().Body(
+		app.Strong().Text(label),
+		app.Span().DataSet("field", field).Text(fmt.Sprint(value)),
+	)
+}
+
 func (d *DevDashboard) getStatusClass() string {
-	switch d.buildStatus {
-	case "success":
+	switch d.BuildStatus {
+	case "succeeded", "skipped":
 		return "status-success"
 	case "failed":
 		return "status-error"
@@ -76,34 +137,85 @@ func (d *DevDashboard) getStatusClass() string {
 	}
 }
 
+func (d *DevDashboard) renderClients() app.UI {
+	if len(d.Clients) == 0 {
+		return app.P().Text("No clients connected.")
+	}
+	return app.Table().Class("client-table").Body(
+		app.THead().Body(
+			app.Tr().Body(
+				app.Th().Text("Kind"),
+				app.Th().Text("Page"),
+				app.Th().Text("Story"),
+				app.Th().Text("User agent"),
+				app.Th().Text("Connected"),
+				app.Th(),
+			),
+		),
+		app.TBody().Body(
+			app.Range(d.Clients).Slice(func(i int) app.UI {
+				c := d.Clients[i]
+				story := ""
+				if c.Component != "" {
+					story = c.Component + " / " + c.Story
+				}
+				return app.Tr().DataSet("client", c.ID).Body(
+					app.Td().Text(c.Kind),
+					app.Td().Text(c.URL),
+					app.Td().Text(story),
+					app.Td().Class("user-agent").Text(c.UserAgent),
+					app.Td().Text(c.ConnectedAt.Format("15:04:05")),
+					app.Td().Body(
+						app.If(c.Component != "", func() app.UI {
+							return d.renderFollowButton(c)
+						}),
+					),
+				)
+			}),
+		),
+	)
+}
+
+// renderTargetButton switches the target served at /app.wasm
+func (d *DevDashboard) renderTargetButton(name string) app.UI {
+	class := "btn btn-secondary target-btn"
+	if name == d.Target {
+		class += " active"
+	}
+	return app.Button().
+		Class(class).
+		DataSet("target", name).
+		Text(name)
+}
+
+// renderFollowButton makes every synced storybook show the client's story
+func (d *DevDashboard) renderFollowButton(c handlers.ClientInfo) app.UI {
+	return app.Button().
+		Class("btn btn-small follow-story").
+		DataSet("component", c.Component).
+		DataSet("story", c.Story).
+		Title("Show this story in all synced browsers").
+		Text("Follow")
+}
+
 func (d *DevDashboard) renderFileChanges() app.UI {
-	if len(d.fileChanges) == 0 {
+	if len(d.FileChanges) == 0 {
 		return app.P().Text("No recent changes detected.")
 	}
 	return app.Ul().Body(
-		app.Range(d.fileChanges).Slice(func(i int) app.UI {
-			return app.Li().Text(d.fileChanges[i])
+		app.Range(d.FileChanges).Slice(func(i int) app.UI {
+			return app.Li().Text(d.FileChanges[i])
 		}),
 	)
 }
 
 func (d *DevDashboard) renderErrors() app.UI {
-	if len(d.compileErrors) == 0 {
+	if len(d.CompileErrors) == 0 {
 		return app.P().Class("no-errors").Text("Clean build - no errors!")
 	}
 	return app.Div().Class("error-log").Body(
-		app.Range(d.compileErrors).Slice(func(i int) app.UI {
-			return app.Pre().Class("error-item").Text(d.compileErrors[i])
+		app.Range(d.CompileErrors).Slice(func(i int) app.UI {
+			return app.Pre().Class("error-item").Text(d.CompileErrors[i])
 		}),
 	)
 }
-
-// Event handlers in go-app v10 require this specific signature
-func (d *DevDashboard) onForceRebuild(ctx app.Context, e app.Event) {
-	app.Log("Force rebuild triggered")
-	// Logic to trigger rebuild via server websocket/api would go here
-}
-
-func (d *DevDashboard) onClearCache(ctx app.Context, e app.Event) {
-	app.Log("Clear cache triggered")
-}
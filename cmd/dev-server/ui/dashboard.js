@@ -0,0 +1,379 @@
+// cmd/dev-server/ui/dashboard.js
+(function() {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const wsUrl = `${protocol}//${window.location.host}/ws`;
+    let socket = null;
+
+    function setField(name, value) {
+        document.querySelectorAll(`[data-field="${name}"]`).forEach(function(el) {
+            el.textContent = value;
+        });
+    }
+
+    function statusClass(status) {
+        switch (status) {
+        case 'succeeded':
+        case 'skipped':
+            return 'status-success';
+        case 'failed':
+            return 'status-error';
+        default:
+            return 'status-pending';
+        }
+    }
+
+    function renderList(containerId, items, empty, render) {
+        const container = document.getElementById(containerId);
+        if (!container) {
+            return;
+        }
+        container.innerHTML = '';
+
+        if (!items || items.length === 0) {
+            container.appendChild(empty());
+            return;
+        }
+        container.appendChild(render(items));
+    }
+
+    function update(data) {
+        const status = document.getElementById('build-status');
+        if (status) {
+            status.className = 'status-indicator ' + statusClass(data.build_status);
+        }
+
+        setField('build_status', data.build_status || '');
+        setField('last_build_time', data.last_build_time && !data.last_build_time.startsWith('0001')
+            ? new Date(data.last_build_time).toLocaleString()
+            : '');
+        setField('last_build_ms', data.last_build_ms);
+        setField('builds_run', data.builds_run);
+        setField('cache_hits', data.cache_hits);
+        setField('cache_entries', data.cache_entries);
+        setField('connected_clients', data.connected_clients);
+        setField('target', data.target || '');
+
+        document.querySelectorAll('.target-btn').forEach(function(button) {
+            button.classList.toggle('active', button.dataset.target === data.target);
+        });
+
+        renderProfiles(data.profiles);
+
+        renderList('clients', data.clients, function() {
+            const p = document.createElement('p');
+            p.textContent = 'No clients connected.';
+            return p;
+        }, function(clients) {
+            const table = document.createElement('table');
+            table.className = 'client-table';
+            const head = table.createTHead().insertRow();
+            ['Kind', 'Page', 'Story', 'User agent', 'Connected', ''].forEach(function(title) {
+                const th = document.createElement('th');
+                th.textContent = title;
+                head.appendChild(th);
+            });
+
+            const body = table.createTBody();
+            clients.forEach(function(client) {
+                const row = body.insertRow();
+                row.dataset.client = client.id;
+                [
+                    client.kind,
+                    client.url,
+                    client.component ? client.component + ' / ' + client.story : '',
+                    client.user_agent,
+                    new Date(client.connected_at).toLocaleTimeString(),
+                ].forEach(function(text, i) {
+                    const cell = row.insertCell();
+                    cell.textContent = text;
+                    if (i === 3) {
+                        cell.className = 'user-agent';
+                    }
+                });
+
+                const action = row.insertCell();
+                if (client.component) {
+                    const button = document.createElement('button');
+                    button.className = 'btn btn-small follow-story';
+                    button.dataset.component = client.component;
+                    button.dataset.story = client.story;
+                    button.title = 'Show this story in all synced browsers';
+                    button.textContent = 'Follow';
+                    action.appendChild(button);
+                }
+            });
+            return table;
+        });
+
+        renderList('file-changes', data.file_changes, function() {
+            const p = document.createElement('p');
+            p.textContent = 'No recent changes detected.';
+            return p;
+        }, function(files) {
+            const ul = document.createElement('ul');
+            files.forEach(function(file) {
+                const li = document.createElement('li');
+                li.textContent = file;
+                ul.appendChild(li);
+            });
+            return ul;
+        });
+
+        renderList('compile-errors', data.compile_errors, function() {
+            const p = document.createElement('p');
+            p.className = 'no-errors';
+            p.textContent = 'Clean build - no errors!';
+            return p;
+        }, function(errors) {
+            const div = document.createElement('div');
+            div.className = 'error-log';
+            errors.forEach(function(err) {
+                const pre = document.createElement('pre');
+                pre.className = 'error-item';
+                pre.textContent = err;
+                div.appendChild(pre);
+            });
+            return div;
+        });
+    }
+
+    // Build profile charts, drawn as SVG bar charts
+
+    const svgNS = 'http://www.w3.org/2000/svg';
+    const phases = [
+        { key: 'load_ms', label: 'load', color: '#6c757d' },
+        { key: 'compile_ms', label: 'compile', color: '#007bff' },
+        { key: 'link_ms', label: 'link', color: '#f0ad4e' },
+        { key: 'write_ms', label: 'write', color: '#28a745' },
+    ];
+
+    function svgElement(name, attrs) {
+        const el = document.createElementNS(svgNS, name);
+        Object.keys(attrs).forEach(function(key) {
+            el.setAttribute(key, attrs[key]);
+        });
+        return el;
+    }
+
+    function formatBytes(bytes) {
+        const sign = bytes < 0 ? '-' : '';
+        bytes = Math.abs(bytes);
+        if (bytes >= 1 << 20) {
+            return sign + (bytes / (1 << 20)).toFixed(2) + ' MiB';
+        }
+        if (bytes >= 1 << 10) {
+            return sign + (bytes / (1 << 10)).toFixed(1) + ' KiB';
+        }
+        return sign + bytes + ' B';
+    }
+
+    // barChart draws one bar per build; segments returns the stacked
+    // parts of a bar as {value, color, title}
+    function barChart(containerId, profiles, segments, total) {
+        const container = document.getElementById(containerId);
+        if (!container || !profiles || profiles.length === 0) {
+            return;
+        }
+
+        const width = 720, height = 160, gap = 2;
+        const barWidth = Math.max(4, Math.floor(width / profiles.length) - gap);
+        const max = Math.max.apply(null, profiles.map(total).concat([1]));
+
+        const svg = svgElement('svg', {
+            viewBox: `0 0 ${width} ${height}`,
+            width: '100%',
+            height: height,
+        });
+
+        profiles.forEach(function(profile, i) {
+            let y = height;
+            segments(profile).forEach(function(segment) {
+                const h = Math.round(segment.value / max * (height - 10));
+                if (h <= 0) {
+                    return;
+                }
+                y -= h;
+                const rect = svgElement('rect', {
+                    x: i * (barWidth + gap),
+                    y: y,
+                    width: barWidth,
+                    height: h,
+                    fill: segment.color,
+                });
+                const title = svgElement('title', {});
+                title.textContent = segment.title;
+                rect.appendChild(title);
+                svg.appendChild(rect);
+            });
+        });
+
+        container.innerHTML = '';
+        container.appendChild(svg);
+        return container;
+    }
+
+    function renderProfiles(profiles) {
+        const phaseChart = barChart('phase-chart', profiles, function(p) {
+            if (p.cache_hit) {
+                return [{ value: p.total_ms, color: '#adb5bd', title: `cache hit: ${p.total_ms} ms` }];
+            }
+            return phases.map(function(phase) {
+                return { value: p[phase.key], color: phase.color, title: `${phase.label}: ${p[phase.key]} ms` };
+            });
+        }, function(p) {
+            return p.total_ms;
+        });
+
+        if (phaseChart) {
+            const legend = document.createElement('div');
+            legend.className = 'chart-legend';
+            phases.forEach(function(phase) {
+                const item = document.createElement('span');
+                item.innerHTML = `<i style="background:${phase.color}"></i>${phase.label}`;
+                legend.appendChild(item);
+            });
+            phaseChart.appendChild(legend);
+        }
+
+        const sizeChart = barChart('size-chart', profiles, function(p) {
+            const delta = p.size_delta === 0 ? '' : ` (${p.size_delta > 0 ? '+' : ''}${formatBytes(p.size_delta)})`;
+            return [{
+                value: p.size,
+                color: p.size_delta > 0 ? '#dc3545' : '#28a745',
+                title: formatBytes(p.size) + delta,
+            }];
+        }, function(p) {
+            return p.size;
+        });
+
+        if (sizeChart) {
+            const last = profiles[profiles.length - 1];
+            const summary = document.createElement('p');
+            summary.className = 'chart-summary';
+            summary.textContent = `Last build: ${formatBytes(last.size)}, ` +
+                `${last.size_delta >= 0 ? '+' : ''}${formatBytes(last.size_delta)} from the previous build`;
+            sizeChart.appendChild(summary);
+        }
+    }
+
+    function refresh() {
+        fetch('/api/dashboard')
+            .then(function(res) { return res.json(); })
+            .then(update)
+            .catch(function(err) { console.error('Failed to load dashboard data:', err); });
+    }
+
+    function bindAction(buttonId, url) {
+        const button = document.getElementById(buttonId);
+        const result = document.getElementById('action-result');
+        if (!button) {
+            return;
+        }
+
+        button.addEventListener('click', function() {
+            button.disabled = true;
+            fetch(url, { method: 'POST' })
+                .then(function(res) { return res.json(); })
+                .then(function(body) {
+                    if (result) {
+                        result.textContent = body.status;
+                    }
+                    refresh();
+                })
+                .catch(function(err) {
+                    if (result) {
+                        result.textContent = 'Request failed: ' + err.message;
+                    }
+                })
+                .finally(function() {
+                    button.disabled = false;
+                });
+        });
+    }
+
+    // Follow buttons ask every synced storybook to show a client's story
+    function bindFollow() {
+        const clients = document.getElementById('clients');
+        if (!clients) {
+            return;
+        }
+
+        clients.addEventListener('click', function(event) {
+            const button = event.target.closest('.follow-story');
+            if (!button || !socket || socket.readyState !== WebSocket.OPEN) {
+                return;
+            }
+            socket.send(JSON.stringify({
+                type: 'select-story',
+                data: { component: button.dataset.component, story: button.dataset.story },
+            }));
+        });
+    }
+
+    // Target buttons switch the build served at /app.wasm; open pages
+    // reload once the target is built
+    function bindTargets() {
+        const targets = document.getElementById('targets');
+        const result = document.getElementById('action-result');
+        if (!targets) {
+            return;
+        }
+
+        targets.addEventListener('click', function(event) {
+            const button = event.target.closest('.target-btn');
+            if (!button || button.classList.contains('active')) {
+                return;
+            }
+            fetch('/api/target', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ target: button.dataset.target }),
+            })
+                .then(function(res) {
+                    if (!res.ok) {
+                        return res.text().then(function(text) { throw new Error(text); });
+                    }
+                    refresh();
+                })
+                .catch(function(err) {
+                    if (result) {
+                        result.textContent = 'Switching target failed: ' + err.message;
+                    }
+                });
+        });
+    }
+
+    function connect() {
+        const ws = new WebSocket(wsUrl);
+        socket = ws;
+
+        ws.onopen = function() {
+            // Identify as a dashboard so that build status updates are
+            // sent here and not to the story pages
+            ws.send(JSON.stringify({
+                type: 'register',
+                data: { kind: 'dashboard', url: window.location.href },
+            }));
+            refresh();
+        };
+
+        ws.onmessage = function(event) {
+            const message = JSON.parse(event.data);
+            if (message.type === 'dashboard') {
+                update(message.data);
+            }
+        };
+
+        ws.onclose = function(event) {
+            // 1001 (going away): the dev server is restarting
+            setField('build_status', event.code === 1001 ? 'restarting' : 'disconnected');
+            setTimeout(connect, 2000);
+        };
+    }
+
+    bindAction('force-rebuild', '/api/rebuild');
+    bindAction('clear-cache', '/api/clear-cache');
+    bindFollow();
+    bindTargets();
+    connect();
+})();
@@ -0,0 +1,45 @@
+// cmd/dev-server/ui/page.go
+package ui
+
+import (
+    "bytes"
+    "embed"
+    "fmt"
+    "html"
+    "net/http"
+
+    "github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+//go:embed dashboard.css dashboard.js
+var assets embed.FS
+
+// Assets serves dashboard.css and dashboard.js
+func Assets() http.Handler {
+    return http.FileServer(http.FS(assets))
+}
+
+// Page renders the dashboard as a complete HTML document. base is the URL
+// path the dashboard assets are served under, e.g. "/__dev/".
+func Page(d *DevDashboard, base string) []byte {
+    var b bytes.Buffer
+
+    fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+    <title>Dev Server Dashboard</title>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <link rel="stylesheet" href="%[1]sdashboard.css">
+</head>
+<body>
+`, html.EscapeString(base))
+    app.PrintHTML(&b, d)
+    fmt.Fprintf(&b, `
+    <script src="%[1]sdashboard.js"></script>
+</body>
+</html>
+`, html.EscapeString(base))
+
+    return b.Bytes()
+}
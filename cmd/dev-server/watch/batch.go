@@ -0,0 +1,81 @@
+// cmd/dev-server/watch/batch.go
+package watch
+
+import (
+    "sync"
+    "time"
+)
+
+// batch collects changed files and reports them once no further change
+// arrived for the debounce time. Each file is reported once per batch.
+type batch struct {
+    debounce time.Duration
+    onChange func(changedFiles []string)
+
+    mu      sync.Mutex
+    pending map[string]struct{} // changed files since the last report
+    order   []string            // pending files in the order they changed
+    timer   *time.Timer
+    closed  bool
+}
+
+func newBatch(debounce time.Duration, onChange func([]string)) *batch {
+    if debounce <= 0 {
+        debounce = DefaultOptions().Debounce
+    }
+    return &batch{
+        debounce: debounce,
+        onChange: onChange,
+        pending:  make(map[string]struct{}),
+    }
+}
+
+// add records a changed file and restarts the debounce timer
+func (b *batch) add(path string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.closed {
+        return
+    }
+
+    if _, ok := b.pending[path]; !ok {
+        b.pending[path] = struct{}{}
+        b.order = append(b.order, path)
+    }
+
+    if b.timer != nil {
+        b.timer.Stop()
+    }
+    b.timer = time.AfterFunc(b.debounce, b.flush)
+}
+
+// flush reports the pending changes
+func (b *batch) flush() {
+    b.mu.Lock()
+    if b.closed || len(b.order) == 0 {
+        b.mu.Unlock()
+        return
+    }
+    changed := b.order
+    b.order = nil
+    b.pending = make(map[string]struct{})
+    b.timer = nil
+    b.mu.Unlock()
+
+    b.onChange(changed)
+}
+
+// close drops pending changes and stops reporting. It reports whether the
+// batch was open.
+func (b *batch) close() bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.closed {
+        return false
+    }
+    b.closed = true
+    if b.timer != nil {
+        b.timer.Stop()
+    }
+    return true
+}
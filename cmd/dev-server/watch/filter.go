@@ -0,0 +1,189 @@
+// cmd/dev-server/watch/filter.go
+package watch
+
+import (
+    "path"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// Options configures what a Watcher reports
+type Options struct {
+    // Include limits watching to paths matching one of these globs. An
+    // empty list includes everything.
+    Include []string
+
+    // Exclude skips paths matching one of these globs. Excluding a
+    // directory excludes everything below it.
+    Exclude []string
+
+    // Extensions are the file extensions that trigger changes, e.g. ".go"
+    Extensions []string
+
+    // Debounce is how long to wait for further changes before reporting
+    Debounce time.Duration
+
+    // Backend selects how changes are detected: BackendAuto (default),
+    // BackendFSNotify or BackendPoll
+    Backend string
+
+    // PollInterval is how often the polling backend scans for changes
+    PollInterval time.Duration
+}
+
+// DefaultOptions returns the options used when nothing is configured
+func DefaultOptions() Options {
+    return Options{
+        Exclude:      []string{".git", "node_modules", ".*"},
+        Extensions:   []string{".go", ".css", ".html"},
+        Debounce:     100 * time.Millisecond,
+        Backend:      BackendAuto,
+        PollInterval: 500 * time.Millisecond,
+    }
+}
+
+// Glob patterns are matched against slash separated paths relative to the
+// watched directory:
+//   - a pattern without a slash matches any single path element, so
+//     "node_modules" or "*.tmp" match at any depth
+//   - a pattern with a slash matches from the root, and "**" matches any
+//     number of path elements, e.g. "web/**/*.wasm"
+type filter struct {
+    root       string
+    include    []string
+    exclude    []string
+    extensions map[string]bool
+}
+
+func newFilter(root string, opts Options) (*filter, error) {
+    absRoot, err := filepath.Abs(root)
+    if err != nil {
+        return nil, err
+    }
+
+    f := &filter{
+        root:       absRoot,
+        include:    cleanPatterns(opts.Include),
+        exclude:    cleanPatterns(opts.Exclude),
+        extensions: make(map[string]bool),
+    }
+    for _, ext := range opts.Extensions {
+        if !strings.HasPrefix(ext, ".") {
+            ext = "." + ext
+        }
+        f.extensions[ext] = true
+    }
+
+    // Reject malformed patterns up front rather than silently never matching
+    for _, p := range append(append([]string{}, f.include...), f.exclude...) {
+        for _, elem := range strings.Split(p, "/") {
+            if _, err := path.Match(elem, ""); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    return f, nil
+}
+
+// excluded reports whether path, or one of its parent directories, matches
+// an exclude pattern
+func (f *filter) excluded(name string) bool {
+    rel, ok := f.rel(name)
+    if !ok {
+        return true
+    }
+    if rel == "." {
+        return false
+    }
+    return matchAnyPrefix(f.exclude, rel)
+}
+
+// matches reports whether a change to the file at name should be reported
+func (f *filter) matches(name string) bool {
+    if !f.extensions[filepath.Ext(name)] || f.excluded(name) {
+        return false
+    }
+    if len(f.include) == 0 {
+        return true
+    }
+
+    rel, _ := f.rel(name)
+    return matchAnyPrefix(f.include, rel)
+}
+
+// rel returns name relative to the watched root, slash separated
+func (f *filter) rel(name string) (string, bool) {
+    abs, err := filepath.Abs(name)
+    if err != nil {
+        return "", false
+    }
+    rel, err := filepath.Rel(f.root, abs)
+    if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+        return "", false
+    }
+    return filepath.ToSlash(rel), true
+}
+
+// matchAnyPrefix reports whether rel or one of its parents matches one of
+// the patterns
+func matchAnyPrefix(patterns []string, rel string) bool {
+    elems := strings.Split(rel, "/")
+    for _, p := range patterns {
+        if !strings.Contains(p, "/") {
+            for _, elem := range elems {
+                if ok, _ := path.Match(p, elem); ok {
+                    return true
+                }
+            }
+            continue
+        }
+
+        patternElems := strings.Split(p, "/")
+        for i := 1; i <= len(elems); i++ {
+            if matchElems(patternElems, elems[:i]) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// matchElems matches path elements against pattern elements, where "**"
+// matches zero or more elements
+func matchElems(pattern, elems []string) bool {
+    for len(pattern) > 0 {
+        if pattern[0] == "**" {
+            for i := 0; i <= len(elems); i++ {
+                if matchElems(pattern[1:], elems[i:]) {
+                    return true
+                }
+            }
+            return false
+        }
+
+        if len(elems) == 0 {
+            return false
+        }
+        if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+            return false
+        }
+        pattern, elems = pattern[1:], elems[1:]
+    }
+    return len(elems) == 0
+}
+
+// cleanPatterns normalizes patterns to slash separated, rootless globs
+func cleanPatterns(patterns []string) []string {
+    var cleaned []string
+    for _, p := range patterns {
+        p = strings.TrimSpace(filepath.ToSlash(p))
+        p = strings.TrimPrefix(p, "./")
+        p = strings.TrimSuffix(p, "/")
+        if p != "" {
+            cleaned = append(cleaned, p)
+        }
+    }
+    return cleaned
+}
@@ -0,0 +1,140 @@
+// cmd/dev-server/watch/poll.go
+package watch
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// fileState is what the polling watcher compares between scans
+type fileState struct {
+    modTime time.Time
+    size    int64
+}
+
+// PollWatcher detects changes by scanning the tree at a fixed interval and
+// comparing modification times and sizes. It works where kernel
+// notifications do not, e.g. on network filesystems and container bind
+// mounts.
+type PollWatcher struct {
+    root     string
+    filter   *filter
+    batch    *batch
+    interval time.Duration
+    files    map[string]fileState
+    stop     chan struct{}
+    done     chan struct{}
+}
+
+// NewPollWatcher watches sourceDir by polling every opts.PollInterval
+func NewPollWatcher(sourceDir string, opts Options, onChange func([]string)) (*PollWatcher, error) {
+    f, err := newFilter(sourceDir, opts)
+    if err != nil {
+        return nil, fmt.Errorf("invalid watch pattern: %v", err)
+    }
+
+    interval := opts.PollInterval
+    if interval <= 0 {
+        interval = DefaultOptions().PollInterval
+    }
+
+    w := &PollWatcher{
+        root:     sourceDir,
+        filter:   f,
+        batch:    newBatch(opts.Debounce, onChange),
+        interval: interval,
+        stop:     make(chan struct{}),
+        done:     make(chan struct{}),
+    }
+
+    // The first scan is the baseline changes are detected against
+    files, err := w.scan()
+    if err != nil {
+        return nil, err
+    }
+    w.files = files
+
+    go w.poll()
+
+    return w, nil
+}
+
+// Close stops polling. Changes that have not been reported yet are
+// dropped. It is safe to call Close more than once.
+func (w *PollWatcher) Close() error {
+    if !w.batch.close() {
+        return nil
+    }
+    close(w.stop)
+    <-w.done
+    return nil
+}
+
+func (w *PollWatcher) poll() {
+    defer close(w.done)
+
+    ticker := time.NewTicker(w.interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-w.stop:
+            return
+        case <-ticker.C:
+        }
+
+        files, err := w.scan()
+        if err != nil {
+            log.Printf("Watcher error: %v", err)
+            continue
+        }
+
+        // Created and modified files
+        for path, state := range files {
+            if prev, ok := w.files[path]; !ok || prev != state {
+                w.batch.add(path)
+            }
+        }
+
+        // Removed files
+        for path := range w.files {
+            if _, ok := files[path]; !ok {
+                w.batch.add(path)
+            }
+        }
+
+        w.files = files
+    }
+}
+
+// scan records the state of every watched file
+func (w *PollWatcher) scan() (map[string]fileState, error) {
+    files := make(map[string]fileState)
+
+    err := filepath.Walk(w.root, func(path string, info os.FileInfo, err error) error {
+        if err != nil {
+            // Files may disappear while we walk the tree
+            if os.IsNotExist(err) {
+                return nil
+            }
+            return err
+        }
+
+        if info.IsDir() {
+            if w.filter.excluded(path) {
+                return filepath.SkipDir
+            }
+            return nil
+        }
+
+        if w.filter.matches(path) && !isTemporaryFile(path) {
+            files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+        }
+        return nil
+    })
+
+    return files, err
+}
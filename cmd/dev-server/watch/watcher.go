@@ -2,41 +2,89 @@
 package watch
 
 import (
+    "fmt"
     "log"
     "os"
     "path/filepath"
+    "sort"
     "strings"
-    "time"
+    "sync"
     
     "github.com/fsnotify/fsnotify"
 )
 
-type Watcher struct {
-    watcher      *fsnotify.Watcher
-    onChange     func(changedFiles []string)
-    ignoredDirs  map[string]bool
-    extensions   map[string]bool
-    debounceTime time.Duration
-    changes      chan []string
+// Watcher reports changed files under a directory until it is closed
+type Watcher interface {
+    Close() error
 }
 
-func NewWatcher(sourceDir string, onChange func([]string)) (*Watcher, error) {
+// Watcher backends
+const (
+    BackendAuto     = "auto"     // fsnotify, falling back to polling if it fails
+    BackendFSNotify = "fsnotify" // kernel notifications
+    BackendPoll     = "poll"     // periodic mtime and size scans
+)
+
+// NewWatcher watches sourceDir recursively with the backend selected in
+// opts and calls onChange with the files changed after each burst of
+// changes
+func NewWatcher(sourceDir string, opts Options, onChange func([]string)) (Watcher, error) {
+    switch opts.Backend {
+    case BackendFSNotify:
+        return NewNotifyWatcher(sourceDir, opts, onChange)
+    case BackendPoll:
+        return NewPollWatcher(sourceDir, opts, onChange)
+    case "", BackendAuto:
+        w, err := NewNotifyWatcher(sourceDir, opts, onChange)
+        if err == nil {
+            return w, nil
+        }
+        log.Printf("fsnotify watcher unavailable (%v), falling back to polling", err)
+        return NewPollWatcher(sourceDir, opts, onChange)
+    default:
+        return nil, fmt.Errorf("unknown watch backend %q", opts.Backend)
+    }
+}
+
+// NotifyWatcher watches for changes with fsnotify
+type NotifyWatcher struct {
+    watcher *fsnotify.Watcher
+    filter  *filter
+    batch   *batch
+    
+    mu    sync.Mutex
+    dirs  map[string]bool // watched directories
+    files map[string]bool // matching files seen in watched directories
+    done  chan struct{}
+}
+
+// NewNotifyWatcher watches sourceDir recursively with fsnotify.
+// Directories created later are watched as they appear.
+func NewNotifyWatcher(sourceDir string, opts Options, onChange func([]string)) (*NotifyWatcher, error) {
+    f, err := newFilter(sourceDir, opts)
+    if err != nil {
+        return nil, fmt.Errorf("invalid watch pattern: %v", err)
+    }
+    
     fswatcher, err := fsnotify.NewWatcher()
     if err != nil {
         return nil, err
     }
     
-    w := &Watcher{
-        watcher:      fswatcher,
-        onChange:     onChange,
-        ignoredDirs:  map[string]bool{".git": true, "node_modules": true},
-        extensions:   map[string]bool{".go": true, ".css": true, ".html": true},
-        debounceTime: 100 * time.Millisecond,
-        changes:      make(chan []string, 100),
+    w := &NotifyWatcher{
+        watcher: fswatcher,
+        filter:  f,
+        batch:   newBatch(opts.Debounce, onChange),
+        dirs:    make(map[string]bool),
+        files:   make(map[string]bool),
+        done:    make(chan struct{}),
     }
     
     // Recursively watch directories
-    w.watchRecursive(sourceDir)
+    if err := w.watchRecursive(sourceDir, false); err != nil {
+        fswatcher.Close()
+        return nil, err
+    }
     
     // Start processing changes
     go w.processChanges()
@@ -44,46 +92,124 @@ func NewWatcher(sourceDir string, onChange func([]string)) (*Watcher, error) {
     return w, nil
 }
 
-func (w *Watcher) watchRecursive(dir string) error {
+// Close stops watching. Changes that have not been reported yet are
+// dropped. It is safe to call Close more than once.
+func (w *NotifyWatcher) Close() error {
+    if !w.batch.close() {
+        return nil
+    }
+    
+    err := w.watcher.Close()
+    <-w.done
+    return err
+}
+
+// watchRecursive adds dir and its subdirectories. With report set, files
+// found along the way are reported as changed, which covers files written
+// into a new directory before its watch was in place.
+func (w *NotifyWatcher) watchRecursive(dir string, report bool) error {
     return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
         if err != nil {
+            // The tree may change while we walk it
+            if report && os.IsNotExist(err) {
+                return nil
+            }
             return err
         }
         
-        // Skip ignored directories
+        // Skip excluded directories
         if info.IsDir() {
-            dirName := filepath.Base(path)
-            if w.ignoredDirs[dirName] {
+            if w.filter.excluded(path) {
                 return filepath.SkipDir
             }
-            return w.watcher.Add(path)
+            return w.addDir(path)
         }
         
-        // Only watch relevant file extensions
-        ext := filepath.Ext(path)
-        if w.extensions[ext] {
-            // Track this file
+        if w.filter.matches(path) && !isTemporaryFile(path) {
+            w.trackFile(path, true)
+            if report {
+                w.batch.add(path)
+            }
         }
-        
         return nil
     })
 }
 
+// addDir watches a single directory
+func (w *NotifyWatcher) addDir(dir string) error {
+    dir = filepath.Clean(dir)
+    
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.dirs[dir] {
+        return nil
+    }
+    if err := w.watcher.Add(dir); err != nil {
+        return err
+    }
+    w.dirs[dir] = true
+    return nil
+}
+
+// removeDir forgets a removed directory and everything below it and
+// returns the files that went with it
+func (w *NotifyWatcher) removeDir(dir string) []string {
+    dir = filepath.Clean(dir)
+    prefix := dir + string(filepath.Separator)
+    
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    for d := range w.dirs {
+        if d == dir || strings.HasPrefix(d, prefix) {
+            // The kernel usually drops the watch itself; ignore the error
+            w.watcher.Remove(d)
+            delete(w.dirs, d)
+        }
+    }
+    
+    var removed []string
+    for f := range w.files {
+        if strings.HasPrefix(f, prefix) {
+            removed = append(removed, f)
+            delete(w.files, f)
+        }
+    }
+    sort.Strings(removed)
+    return removed
+}
+
+// trackFile records that a matching file exists (or no longer does)
+func (w *NotifyWatcher) trackFile(path string, exists bool) {
+    path = filepath.Clean(path)
+    
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if exists {
+        w.files[path] = true
+    } else {
+        delete(w.files, path)
+    }
+}
+
+// isWatchedDir reports whether path is a watched directory
+func (w *NotifyWatcher) isWatchedDir(path string) bool {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.dirs[filepath.Clean(path)]
+}
+
 // shouldProcessEvent determines whether a filesystem event should trigger a rebuild
-func (w *Watcher) shouldProcessEvent(event fsnotify.Event) bool {
+func (w *NotifyWatcher) shouldProcessEvent(event fsnotify.Event) bool {
     // Filter by file operation type
     if !isRelevantFileOperation(event.Op) {
         return false
     }
     
-    // Check if the file/directory is in ignored paths
-    if w.isIgnoredPath(event.Name) {
+    // Check include/exclude patterns and extensions
+    if !w.filter.matches(event.Name) && !isDirEvent(event.Name) {
         return false
     }
-    
-    // Check for file extension (skip non-source files)
-    ext := filepath.Ext(event.Name)
-    if !w.extensions[ext] && !isDirEvent(event.Name) {
+    if w.filter.excluded(event.Name) {
         return false
     }
     
@@ -102,42 +228,6 @@ func isRelevantFileOperation(op fsnotify.Op) bool {
     return op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0
 }
 
-// isIgnoredPath checks if a path should be ignored based on directory patterns
-func (w *Watcher) isIgnoredPath(path string) bool {
-    // Convert to absolute path for consistent checking
-    absPath, err := filepath.Abs(path)
-    if err != nil {
-        // If we can't get absolute path, use relative
-        absPath = path
-    }
-    
-    // Split path into components
-    components := strings.Split(filepath.ToSlash(absPath), "/")
-    
-    // Check each component against ignored directories
-    for _, component := range components {
-        if w.ignoredDirs[component] {
-            return true
-        }
-    }
-    
-    // Check for hidden directories (starting with .)
-    for _, component := range components {
-        if strings.HasPrefix(component, ".") && component != "." && component != ".." {
-            // Allow .gitignore, .env, etc. but skip .git, .idea, etc.
-            if w.ignoredDirs[component] {
-                return true
-            }
-            // Consider ignoring all dot directories except explicitly allowed ones
-            if isDir, _ := isDirectory(filepath.Join(components[:len(components)-1]...)); isDir {
-                return true
-            }
-        }
-    }
-    
-    return false
-}
-
 // isTemporaryFile checks for common temporary/backup file patterns
 func isTemporaryFile(path string) bool {
     base := filepath.Base(path)
@@ -159,11 +249,6 @@ func isTemporaryFile(path string) bool {
         }
     }
     
-    // Check for files in system temporary directories
-    if strings.Contains(path, "/tmp/") || strings.Contains(path, "/Temp/") {
-        return true
-    }
-    
     return false
 }
 
@@ -177,18 +262,8 @@ func isDirEvent(path string) bool {
     return info.IsDir()
 }
 
-// isDirectory checks if a path is a directory (helper function)
-func isDirectory(path string) (bool, error) {
-    info, err := os.Stat(path)
-    if err != nil {
-        return false, err
-    }
-    return info.IsDir(), nil
-}
-
-func (w *Watcher) processChanges() {
-    var timer *time.Timer
-    var changedFiles []string
+func (w *NotifyWatcher) processChanges() {
+    defer close(w.done)
     
     for {
         select {
@@ -196,25 +271,7 @@ func (w *Watcher) processChanges() {
             if !ok {
                 return
             }
-            
-            // Filter relevant changes
-            if !w.shouldProcessEvent(event) {
-                continue
-            }
-            
-            ext := filepath.Ext(event.Name)
-            if w.extensions[ext] {
-                changedFiles = append(changedFiles, event.Name)
-            }
-            
-            // Debounce multiple changes
-            if timer != nil {
-                timer.Stop()
-            }
-            timer = time.AfterFunc(w.debounceTime, func() {
-                w.onChange(changedFiles)
-                changedFiles = nil
-            })
+            w.handleEvent(event)
             
         case err, ok := <-w.watcher.Errors:
             if !ok {
@@ -224,3 +281,37 @@ func (w *Watcher) processChanges() {
         }
     }
 }
+
+// handleEvent keeps the set of watched directories in sync and records
+// relevant file changes
+func (w *NotifyWatcher) handleEvent(event fsnotify.Event) {
+    // A removed or renamed directory no longer exists under its name. Its
+    // files are gone too, e.g. after rm -r or a move out of the tree,
+    // which reports no events for them.
+    if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && w.isWatchedDir(event.Name) {
+        for _, f := range w.removeDir(event.Name) {
+            w.batch.add(f)
+        }
+        return
+    }
+    
+    // Start watching new directories, including ones moved into the tree
+    if event.Op&fsnotify.Create != 0 && isDirEvent(event.Name) {
+        if !w.filter.excluded(event.Name) {
+            if err := w.watchRecursive(event.Name, true); err != nil {
+                log.Printf("Failed to watch %s: %v", event.Name, err)
+            }
+        }
+        return
+    }
+    
+    // Filter relevant changes
+    if !w.shouldProcessEvent(event) {
+        return
+    }
+    
+    if w.filter.matches(event.Name) {
+        w.trackFile(event.Name, event.Op&(fsnotify.Remove|fsnotify.Rename) == 0)
+        w.batch.add(event.Name)
+    }
+}
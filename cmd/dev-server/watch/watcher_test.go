@@ -0,0 +1,207 @@
+// cmd/dev-server/watch/watcher_test.go
+package watch
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// testDebounce keeps the tests fast while still merging bursts of events
+const testDebounce = 50 * time.Millisecond
+
+// newTestWatcher watches a new temporary directory and returns the batches
+// of changed files it reports
+func newTestWatcher(t *testing.T) (*NotifyWatcher, string, chan []string) {
+    t.Helper()
+
+    dir := t.TempDir()
+    changes := make(chan []string, 16)
+
+    opts := DefaultOptions()
+    opts.Debounce = testDebounce
+    w, err := NewNotifyWatcher(dir, opts, func(files []string) {
+        changes <- files
+    })
+    if err != nil {
+        t.Fatalf("NewNotifyWatcher: %v", err)
+    }
+    t.Cleanup(func() { w.Close() })
+
+    return w, dir, changes
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+    t.Helper()
+
+    deadline := time.Now().Add(5 * time.Second)
+    for !cond() {
+        if time.Now().After(deadline) {
+            t.Fatalf("timed out waiting for %s", what)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+// nextChange returns the next reported batch
+func nextChange(t *testing.T, changes chan []string) []string {
+    t.Helper()
+
+    select {
+    case files := <-changes:
+        return files
+    case <-time.After(5 * time.Second):
+        t.Fatal("timed out waiting for a change")
+        return nil
+    }
+}
+
+func writeFile(t *testing.T, path, content string) {
+    t.Helper()
+
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestNewDirectoryIsWatched(t *testing.T) {
+    w, dir, changes := newTestWatcher(t)
+
+    sub := filepath.Join(dir, "sub")
+    if err := os.Mkdir(sub, 0755); err != nil {
+        t.Fatal(err)
+    }
+    waitFor(t, "the new directory to be watched", func() bool {
+        return w.isWatchedDir(sub)
+    })
+
+    file := filepath.Join(sub, "a.go")
+    writeFile(t, file, "package sub\n")
+
+    files := nextChange(t, changes)
+    if len(files) != 1 || files[0] != file {
+        t.Fatalf("got changes %v, want [%s]", files, file)
+    }
+}
+
+func TestRemovedDirectoryIsDropped(t *testing.T) {
+    dir := t.TempDir()
+    sub := filepath.Join(dir, "sub", "nested")
+    if err := os.MkdirAll(sub, 0755); err != nil {
+        t.Fatal(err)
+    }
+
+    opts := DefaultOptions()
+    opts.Debounce = testDebounce
+    w, err := NewNotifyWatcher(dir, opts, func([]string) {})
+    if err != nil {
+        t.Fatalf("NewNotifyWatcher: %v", err)
+    }
+    defer w.Close()
+
+    if !w.isWatchedDir(sub) {
+        t.Fatalf("%s is not watched", sub)
+    }
+
+    if err := os.RemoveAll(filepath.Join(dir, "sub")); err != nil {
+        t.Fatal(err)
+    }
+    waitFor(t, "the removed directories to be dropped", func() bool {
+        return !w.isWatchedDir(sub) && !w.isWatchedDir(filepath.Join(dir, "sub"))
+    })
+}
+
+func TestRemovedDirectoryReportsFiles(t *testing.T) {
+    for _, tc := range []struct {
+        name   string
+        remove func(dir string) error
+    }{
+        {"rm -r", os.RemoveAll},
+        {"moved out of the tree", func(dir string) error {
+            return os.Rename(dir, filepath.Join(t.TempDir(), "moved"))
+        }},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            w, dir, changes := newTestWatcher(t)
+
+            sub := filepath.Join(dir, "sub")
+            nested := filepath.Join(sub, "nested")
+            if err := os.MkdirAll(nested, 0755); err != nil {
+                t.Fatal(err)
+            }
+            waitFor(t, "the new directories to be watched", func() bool {
+                return w.isWatchedDir(nested)
+            })
+            a, b := filepath.Join(sub, "a.go"), filepath.Join(nested, "b.go")
+            writeFile(t, a, "package sub\n")
+            writeFile(t, b, "package nested\n")
+            nextChange(t, changes)
+
+            if err := tc.remove(sub); err != nil {
+                t.Fatal(err)
+            }
+
+            // rm -r may report the files in more than one batch
+            seen := make(map[string]bool)
+            for !seen[a] || !seen[b] {
+                for _, f := range nextChange(t, changes) {
+                    seen[f] = true
+                }
+            }
+            if w.isWatchedDir(sub) {
+                t.Errorf("%s is still watched", sub)
+            }
+        })
+    }
+}
+
+func TestDuplicateEventsAreMerged(t *testing.T) {
+    _, dir, changes := newTestWatcher(t)
+
+    file := filepath.Join(dir, "a.go")
+    for i := 0; i < 5; i++ {
+        writeFile(t, file, "package a\n")
+    }
+
+    files := nextChange(t, changes)
+    if len(files) != 1 || files[0] != file {
+        t.Fatalf("got changes %v, want [%s]", files, file)
+    }
+
+    select {
+    case files := <-changes:
+        t.Fatalf("got a second batch %v", files)
+    case <-time.After(4 * testDebounce):
+    }
+}
+
+func TestCloseStopsEvents(t *testing.T) {
+    w, dir, changes := newTestWatcher(t)
+
+    if err := w.Close(); err != nil {
+        t.Fatalf("Close: %v", err)
+    }
+
+    select {
+    case _, ok := <-w.watcher.Events:
+        if ok {
+            t.Fatal("event channel still delivers events after Close")
+        }
+    case <-time.After(time.Second):
+        t.Fatal("event channel not closed after Close")
+    }
+
+    writeFile(t, filepath.Join(dir, "a.go"), "package a\n")
+    select {
+    case files := <-changes:
+        t.Fatalf("got changes %v after Close", files)
+    case <-time.After(4 * testDebounce):
+    }
+
+    // Closing again is harmless
+    if err := w.Close(); err != nil {
+        t.Fatalf("second Close: %v", err)
+    }
+}
@@ -0,0 +1,81 @@
+// cmd/example-app/main.go
+package main
+
+// A reference application that consumes the component library the way a
+// downstream project would: it imports individual component packages and
+// is built WITHOUT the dev tag, so no stories or storybook code is linked.
+//
+//	GOOS=js GOARCH=wasm go build -o build/example/web/app.wasm ./cmd/example-app
+//	go build -o example-app ./cmd/example-app && ./example-app
+
+import (
+	"fmt"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/button"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/input_text"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/panel"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/phase_banner"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/static_message"
+	"github.com/mmcnicol/go-app-component-library/pkg/components/toggle_switch"
+)
+
+// signUp is a small form built entirely from library components
+type signUp struct {
+	app.Compo
+
+	name       string
+	newsletter bool
+	submitted  bool
+}
+
+func (s *signUp) Render() app.UI {
+	return app.Div().Style("max-width", "480px").Style("margin", "40px auto").Body(
+		&phase_banner.PhaseBanner{
+			Phase:   "Beta",
+			Message: app.Text("This is an example application built with the component library."),
+		},
+		&panel.Panel{
+			Title: "Sign up",
+			Content: app.Div().Body(
+				&input_text.InputText{
+					Value:       s.name,
+					Placeholder: "Your name",
+					OnInput: func(ctx app.Context, val string) {
+						s.name = val
+					},
+				},
+				&toggle_switch.ToggleSwitch{
+					Label: "Subscribe to the newsletter",
+					IsOn:  s.newsletter,
+					OnClick: func(ctx app.Context, val bool) {
+						s.newsletter = val
+					},
+				},
+				&button.Button{
+					Label: "Submit",
+					Look:  button.LookPrimary,
+					OnClick: func(ctx app.Context, e app.Event) {
+						s.submitted = true
+					},
+				},
+			),
+		},
+		app.If(s.submitted, func() app.UI {
+			return &static_message.StaticMessage{
+				Severity: "success",
+				Summary:  "Thanks",
+				Detail:   fmt.Sprintf("Welcome aboard, %s.", s.name),
+			}
+		}),
+	)
+}
+
+func main() {
+	app.Route("/", func() app.Composer { return &signUp{} })
+
+	// Blocks in the browser; returns immediately on the server
+	app.RunWhenOnBrowser()
+
+	serve()
+}
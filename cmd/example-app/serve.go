@@ -0,0 +1,31 @@
+//go:build !wasm
+// cmd/example-app/serve.go
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
+)
+
+// serve runs the HTTP server that delivers the app shell, the WASM binary
+// and the bundled component stylesheets
+func serve() {
+	bundle, err := styles.Build("web/style")
+	if err != nil {
+		log.Fatalf("Failed to bundle stylesheets: %v", err)
+	}
+
+	http.Handle("/", &app.Handler{
+		Name:        "go-app component library example",
+		Description: "An example application built with the go-app component library",
+		Styles:      []string{bundle.Path()},
+		Resources:   app.LocalDir("build/example"),
+	})
+	http.Handle(bundle.Path(), bundle)
+
+	log.Println("Serving example app at http://localhost:8000")
+	log.Fatal(http.ListenAndServe(":8000", nil))
+}
@@ -0,0 +1,7 @@
+//go:build wasm
+// cmd/example-app/serve_wasm.go
+package main
+
+// serve is a no-op in the browser: the app is already running there, and
+// the HTTP server setup in serve.go is only compiled for the server.
+func serve() {}
@@ -4,69 +4,114 @@ package main
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 	"github.com/mmcnicol/go-app-component-library/pkg/storybook"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 	
-    // Import your components so their init() functions run and register stories
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/built_in"
-	//_ "github.com/mmcnicol/go-app-component-library/pkg/components/hello"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/phase_banner"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/toggle_switch"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/select_one"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/input_text"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/static_message"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/icon"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/input_text_area"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/tree"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/progress"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/button"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/label"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/table"
-	_ "github.com/mmcnicol/go-app-component-library/pkg/components/panel"
+	// Import every component so their init() functions register stories
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/all"
 
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
+	var (
+		addr      = flag.String("addr", envOr("STORYBOOK_ADDR", ":8080"), "Listen address (env STORYBOOK_ADDR)")
+		tlsCert   = flag.String("tls-cert", envOr("STORYBOOK_TLS_CERT", ""), "TLS certificate file (env STORYBOOK_TLS_CERT)")
+		tlsKey    = flag.String("tls-key", envOr("STORYBOOK_TLS_KEY", ""), "TLS key file (env STORYBOOK_TLS_KEY)")
+		basePath  = flag.String("base-path", envOr("STORYBOOK_BASE_PATH", ""), "Path prefix the storybook is mounted under, e.g. /storybook (env STORYBOOK_BASE_PATH)")
+		resources = flag.String("resources", envOr("STORYBOOK_RESOURCES", "."), "Directory containing the web/ resources folder (env STORYBOOK_RESOURCES)")
+	)
+	flag.Parse()
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Fatal("Both -tls-cert and -tls-key must be set to enable TLS")
+	}
+
+	prefix := strings.TrimRight(*basePath, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+
 	// CRITICAL: The server MUST know the route exists
 	// Route "/" to the Storybook shell
 	app.Route("/", func() app.Composer { return &storybook.Shell{} })
 
+	// Bundle the stylesheets declared by the imported components
+	bundle, err := styles.Build(filepath.Join(*resources, "web", "style"))
+	if err != nil {
+		log.Fatalf("Failed to bundle stylesheets: %v", err)
+	}
+
 	h := &app.Handler{
 		Name:      "go-app component library",
 		Description: "A go-app UI library using Go and WebAssembly",
 		Author:      "mmcnicol",
-		Styles: []string{
-			"/web/style/variables.css", // Load this FIRST
-			"/web/style/main.css",
-			"/web/style/phase_banner.css",
-			"/web/style/toggle_switch.css",
-			"/web/style/select_one.css",
-			"/web/style/input_text.css",
-			"/web/style/input_text_area.css",
-			"/web/style/tree.css",
-			"/web/style/static_message.css",
-			"/web/style/progress.css",
-			"/web/style/button.css",
-			"/web/style/icon.css",
-			"/web/style/label.css",
-			"/web/style/table.css",
-			"/web/style/sortable_table.css",
-			"/web/style/data_grid.css",
-			"/web/style/chart.css",
-		},
+		Styles:      []string{bundle.Path()},
 		Icon: app.Icon{
 			//Default: "/web/images/logo.png",
 		},
-		//Resources: app.LocalDir("web"),
+		Resources: newResourceDir(*resources, prefix),
 	}
 
-	http.Handle("/", h)
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	mux.Handle(bundle.Path(), bundle)
 
 	// Example API endpoint
-	http.HandleFunc("/api/data", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/data", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"status": "ok"}`))
 	})
 
-	log.Println("Serving at http://localhost:8080")
-	http.ListenAndServe(":8080", nil)
+	var handler http.Handler = mux
+	if prefix != "" {
+		handler = http.StripPrefix(prefix, mux)
+	}
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: handler,
+	}
+
+	go func() {
+		var err error
+		if *tlsCert != "" {
+			log.Printf("Serving at https://%s%s/", *addr, prefix)
+			err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+		} else {
+			log.Printf("Serving at http://%s%s/", *addr, prefix)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM, then let in-flight requests finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Graceful shutdown failed: %v", err)
+	}
+}
+
+// envOr returns the value of the environment variable key, or def if unset
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,66 @@
+// cmd/server/resources.go
+package main
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmcnicol/go-app-component-library/pkg/wasmserve"
+)
+
+// resourceDir serves static resources (/web/*) from a local directory and
+// resolves their URLs under an optional base path. go-app's PrefixedLocation
+// only rewrites URLs and cannot serve files, and LocalDir assumes the
+// resources live in the working directory.
+//
+// WebAssembly binaries are served compressed, from the .br and .gz files
+// written next to them at build time when present, with ETags so that
+// browsers only download a binary again when it changed.
+type resourceDir struct {
+	dir    string
+	files  http.Handler
+	wasm   *wasmserve.Server
+	prefix string
+}
+
+func newResourceDir(dir, prefix string) resourceDir {
+	return resourceDir{
+		dir:    dir,
+		files:  http.FileServer(http.Dir(dir)),
+		wasm:   wasmserve.New(true),
+		prefix: strings.TrimRight(prefix, "/"),
+	}
+}
+
+// Resolve returns the URL of a resource as seen by the browser
+func (d resourceDir) Resolve(location string) string {
+	if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") {
+		return location
+	}
+	if location == "/" || location == "" {
+		if d.prefix == "" {
+			return "/"
+		}
+		return d.prefix
+	}
+	return d.prefix + "/" + strings.TrimLeft(location, "/")
+}
+
+// ServeHTTP serves a resource from the local directory. go-app rewrites
+// /app.wasm requests to the resolved (prefixed) URL, so the prefix is
+// stripped again here.
+func (d resourceDir) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r2 := *r
+	u := *r.URL
+	u.Path = strings.TrimPrefix(u.Path, d.prefix)
+	r2.URL = &u
+
+	if strings.HasSuffix(u.Path, ".wasm") {
+		name := path.Clean("/" + u.Path)
+		d.wasm.ServeFile(w, &r2, filepath.Join(d.dir, filepath.FromSlash(name)))
+		return
+	}
+	d.files.ServeHTTP(w, &r2)
+}
@@ -0,0 +1,126 @@
+// cmd/storybook-export/main.go
+package main
+
+// storybook-export writes the storybook as a static website that can be
+// published to any static file server:
+//
+//	go run ./cmd/storybook-export -out dist
+//	go run ./cmd/storybook-export -out dist -prefix /storybook
+//
+// Stories are deep-linked with query parameters on the root page
+// (/?component=Form&story=Button), so no server-side routing is needed.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/storybook"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
+	"github.com/mmcnicol/go-app-component-library/pkg/wasmserve"
+
+	// Import every component so their init() functions register stylesheets
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/all"
+)
+
+func main() {
+	var (
+		outDir   = flag.String("out", "dist", "Output directory")
+		prefix   = flag.String("prefix", "", "Path prefix the site is hosted under (e.g. /storybook)")
+		styleDir = flag.String("styles", "web/style", "Directory containing component stylesheets")
+		wasmPath = flag.String("wasm", "", "Prebuilt storybook WASM binary (built from ./cmd/wasm when empty)")
+	)
+	flag.Parse()
+
+	app.Route("/", func() app.Composer { return &storybook.Shell{} })
+
+	webDir := filepath.Join(*outDir, "web")
+	if err := os.MkdirAll(filepath.Join(webDir, "style"), 0755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	// WASM binary
+	if *wasmPath == "" {
+		if err := buildWasm(filepath.Join(webDir, "app.wasm")); err != nil {
+			log.Fatalf("Failed to build WebAssembly: %v", err)
+		}
+	} else if err := copyFile(*wasmPath, filepath.Join(webDir, "app.wasm")); err != nil {
+		log.Fatalf("Failed to copy WebAssembly: %v", err)
+	}
+
+	// Static hosts that support pre-compressed files (for example nginx
+	// gzip_static or brotli_static) serve these instead
+	if err := wasmserve.Precompress(filepath.Join(webDir, "app.wasm")); err != nil {
+		log.Fatalf("Failed to compress WebAssembly: %v", err)
+	}
+
+	// Component stylesheets
+	bundle, err := styles.Build(*styleDir)
+	if err != nil {
+		log.Fatalf("Failed to bundle stylesheets: %v", err)
+	}
+	bundlePath := filepath.Join(webDir, "style", bundle.Name())
+	if err := os.WriteFile(bundlePath, bundle.Content, 0644); err != nil {
+		log.Fatalf("Failed to write stylesheet bundle: %v", err)
+	}
+
+	h := &app.Handler{
+		Name:        "go-app component library",
+		Description: "A go-app UI library using Go and WebAssembly",
+		Author:      "mmcnicol",
+		Styles:      []string{bundle.Path()},
+	}
+	if *prefix != "" {
+		h.Resources = app.PrefixedLocation(*prefix)
+	}
+
+	// index.html, app.js, wasm_exec.js, manifest and service worker
+	if err := app.GenerateStaticWebsite(*outDir, h); err != nil {
+		log.Fatalf("Failed to generate static website: %v", err)
+	}
+
+	log.Printf("Storybook exported to %s", *outDir)
+}
+
+// buildWasm compiles the storybook entry point with the dev tag so that
+// stories are included
+func buildWasm(outputPath string) error {
+	cmd := exec.Command("go", "build",
+		"-o", outputPath,
+		"-tags", "dev",
+		"-ldflags", "-s -w",
+		"./cmd/wasm",
+	)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Printf("Building WebAssembly: %s", outputPath)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build failed: %v", err)
+	}
+	return nil
+}
+
+// copyFile copies src to dst, replacing dst if it exists
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
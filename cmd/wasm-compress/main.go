@@ -0,0 +1,30 @@
+// cmd/wasm-compress/main.go
+package main
+
+// wasm-compress writes brotli (.br) and gzip (.gz) versions of WebAssembly
+// binaries next to them, so that the servers do not have to compress them
+// on the fly:
+//
+//	go run ./cmd/wasm-compress web/app.wasm
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mmcnicol/go-app-component-library/pkg/wasmserve"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: wasm-compress file.wasm...")
+		os.Exit(2)
+	}
+
+	for _, path := range os.Args[1:] {
+		if err := wasmserve.Precompress(path); err != nil {
+			log.Fatalf("Failed to compress %s: %v", path, err)
+		}
+		log.Printf("Compressed %s", path)
+	}
+}
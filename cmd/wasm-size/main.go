@@ -0,0 +1,135 @@
+// cmd/wasm-size/main.go
+package main
+
+// wasm-size attributes the size of the storybook WebAssembly binary to the
+// Go packages it is built from, using the function names the linker writes
+// to the binary's name section:
+//
+//	go run ./cmd/wasm-size                          # build ./cmd/wasm and report
+//	go run ./cmd/wasm-size -match pkg/components    # component packages only
+//	go run ./cmd/wasm-size -json > base.json        # save a report
+//	go run ./cmd/wasm-size -base base.json -threshold 16384
+//
+// With -base the packages whose size changed are listed, and the command
+// fails when a package (or the whole binary) grew by more than -threshold
+// bytes. Either side of a comparison may be a saved report or a .wasm file.
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		pkg       = flag.String("pkg", "./cmd/wasm", "Package to build when no binary is given")
+		tags      = flag.String("tags", "dev", "Build tags")
+		ldflags   = flag.String("ldflags", "-w", "Linker flags (-s strips the names the report needs)")
+		asJSON    = flag.Bool("json", false, "Write the report as JSON")
+		sortBy    = flag.String("sort", "size", "Sort packages by size, name or functions")
+		top       = flag.Int("top", 30, "Number of packages to list (0 for all)")
+		match     = flag.String("match", "", "Only list packages whose path contains this string")
+		basePath  = flag.String("base", "", "Report or binary to compare against")
+		threshold = flag.Int64("threshold", 0, "Fail when a package grows by more than this many bytes (with -base)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: wasm-size [flags] [app.wasm | report.json]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	names, err := listPackages(*pkg, *tags)
+	if err != nil {
+		log.Printf("Listing packages failed, package names may be inaccurate: %v", err)
+	}
+
+	var report *Report
+	switch flag.NArg() {
+	case 0:
+		report, err = buildReport(*pkg, *tags, *ldflags, names)
+	case 1:
+		report, err = loadReport(flag.Arg(0), names)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	sortPackages(report.Packages, *sortBy)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *basePath == "" {
+		report.writeTable(os.Stdout, report.filter(*match), *top)
+		return
+	}
+
+	base, err := loadReport(*basePath, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if n := writeComparison(os.Stdout, base, report, *match, *top, *threshold); n > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d size regressions above %d bytes\n", n, *threshold)
+		os.Exit(1)
+	}
+}
+
+// listPackages returns the packages the binaries of this module can link:
+// the dependencies of pkg and of every package in the module
+func listPackages(pkg, tags string) (*packageNames, error) {
+	cmd := exec.Command("go", "list", "-deps", "-tags", tags, pkg, "./...")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return newPackageNames(nil), fmt.Errorf("go list failed: %v", err)
+	}
+	return newPackageNames(strings.Fields(string(out))), nil
+}
+
+// buildReport builds pkg for the browser into a temporary directory and
+// analyzes the result
+func buildReport(pkg, tags, ldflags string, names *packageNames) (*Report, error) {
+	dir, err := os.MkdirTemp("", "wasm-size")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "app.wasm")
+	cmd := exec.Command("go", "build",
+		"-o", output,
+		"-tags", tags,
+		"-ldflags", ldflags,
+		pkg,
+	)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	log.Printf("Building WebAssembly: %s", pkg)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go build failed: %v", err)
+	}
+
+	report, err := loadReport(output, names)
+	if err != nil {
+		return nil, err
+	}
+	report.Binary = pkg
+	return report, nil
+}
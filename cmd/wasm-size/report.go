@@ -0,0 +1,304 @@
+// cmd/wasm-size/report.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Report attributes the size of a WebAssembly binary to Go packages
+type Report struct {
+	Binary   string           `json:"binary"`
+	Total    int64            `json:"total"`
+	Code     int64            `json:"code"` // sum of all function bodies
+	Data     int64            `json:"data"`
+	Sections map[string]int64 `json:"sections"` // everything else, by section
+	Packages []PackageSize    `json:"packages"`
+	Unnamed  int              `json:"unnamed"` // functions without a name (stripped binary)
+}
+
+// PackageSize is the code size of one package
+type PackageSize struct {
+	Package   string `json:"package"`
+	Size      int64  `json:"size"`
+	Functions int    `json:"functions"`
+}
+
+// newReport groups the functions of m by package
+func newReport(binary string, m *module, names *packageNames) *Report {
+	r := &Report{
+		Binary:   binary,
+		Total:    m.fileSize,
+		Data:     m.dataSize,
+		Sections: m.sections,
+	}
+
+	byPackage := make(map[string]*PackageSize)
+	for _, f := range m.functions {
+		if f.name == "" {
+			r.Unnamed++
+		}
+		pkg := names.packageOf(f.name)
+		p, ok := byPackage[pkg]
+		if !ok {
+			p = &PackageSize{Package: pkg}
+			byPackage[pkg] = p
+		}
+		p.Size += f.size
+		p.Functions++
+		r.Code += f.size
+	}
+
+	for _, p := range byPackage {
+		r.Packages = append(r.Packages, *p)
+	}
+	sortPackages(r.Packages, "size")
+	return r
+}
+
+// packageNames maps the function names of the name section back to import
+// paths. The wasm linker replaces every character other than letters,
+// digits, '.' and '_' with '_', so "github.com/x/y-z.(*T).M" is written as
+// "github.com_x_y_z.__T_.M" and the package cannot be read off the name.
+type packageNames struct {
+	mangled map[string]string // mangled import path -> import path
+}
+
+// newPackageNames indexes the given import paths
+func newPackageNames(paths []string) *packageNames {
+	n := &packageNames{mangled: make(map[string]string, len(paths))}
+	for _, path := range paths {
+		n.mangled[mangle(path)] = path
+	}
+	return n
+}
+
+// packageOf returns the import path of the package a function belongs to.
+// Functions the compiler generates are grouped separately, and names of
+// packages that were not indexed are cut at their first dot.
+func (n *packageNames) packageOf(name string) string {
+	switch {
+	case name == "":
+		return "(unnamed)"
+	case strings.HasPrefix(name, "type_.") || name == "go_buildid":
+		return "(generated)"
+	}
+
+	// The longest indexed package followed by a dot wins, which keeps
+	// "pkg/app" from being attributed to a "pkg" package
+	for i := len(name) - 1; i > 0; i-- {
+		if name[i] != '.' {
+			continue
+		}
+		if path, ok := n.mangled[name[:i]]; ok {
+			return path
+		}
+	}
+
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return name[:i]
+	}
+	// Assembly helpers such as wasm_export_run
+	return "(runtime)"
+}
+
+// mangle converts an import path the way the wasm linker converts symbol
+// names
+func mangle(path string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, path)
+}
+
+func sortPackages(packages []PackageSize, by string) {
+	sort.SliceStable(packages, func(i, j int) bool {
+		a, b := packages[i], packages[j]
+		switch by {
+		case "name":
+			return a.Package < b.Package
+		case "functions":
+			if a.Functions != b.Functions {
+				return a.Functions > b.Functions
+			}
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Package < b.Package
+	})
+}
+
+// filter keeps the packages whose path contains match
+func (r *Report) filter(match string) []PackageSize {
+	if match == "" {
+		return r.Packages
+	}
+	var packages []PackageSize
+	for _, p := range r.Packages {
+		if strings.Contains(p.Package, match) {
+			packages = append(packages, p)
+		}
+	}
+	return packages
+}
+
+// writeTable prints the top packages as an aligned table
+func (r *Report) writeTable(w io.Writer, packages []PackageSize, top int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "SIZE\t%%\tFUNCS\t PACKAGE\n")
+	for i, p := range packages {
+		if top > 0 && i == top {
+			fmt.Fprintf(tw, "\t\t\t ... %d more packages\n", len(packages)-top)
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%.1f\t%d\t %s\n", formatBytes(p.Size), percent(p.Size, r.Total), p.Functions, p.Package)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%s  %s\n", formatBytes(r.Total), r.Binary)
+	fmt.Fprintf(w, "  code:  %s (%.1f%%)\n", formatBytes(r.Code), percent(r.Code, r.Total))
+	fmt.Fprintf(w, "  data:  %s (%.1f%%)\n", formatBytes(r.Data), percent(r.Data, r.Total))
+	var other int64
+	for _, size := range r.Sections {
+		other += size
+	}
+	fmt.Fprintf(w, "  other: %s (%.1f%%)\n", formatBytes(other), percent(other, r.Total))
+	if r.Unnamed > 0 {
+		fmt.Fprintf(w, "\nwarning: %d functions have no name; build without -ldflags -s\n", r.Unnamed)
+	}
+}
+
+// loadReport reads a report written with -json, or analyzes a binary
+func loadReport(path string, names *packageNames) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, ".json") {
+		var r Report
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return &r, nil
+	}
+
+	m, err := parseModule(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return newReport(path, m, names), nil
+}
+
+// packageDelta is the change of one package between two reports
+type packageDelta struct {
+	Package string
+	Old     int64
+	New     int64
+}
+
+func (d packageDelta) delta() int64 { return d.New - d.Old }
+
+// compare lists the packages whose size changed between base and r,
+// largest growth first
+func compare(base, r *Report) []packageDelta {
+	sizes := make(map[string]*packageDelta)
+	get := func(pkg string) *packageDelta {
+		d, ok := sizes[pkg]
+		if !ok {
+			d = &packageDelta{Package: pkg}
+			sizes[pkg] = d
+		}
+		return d
+	}
+	for _, p := range base.Packages {
+		get(p.Package).Old = p.Size
+	}
+	for _, p := range r.Packages {
+		get(p.Package).New = p.Size
+	}
+
+	var deltas []packageDelta
+	for _, d := range sizes {
+		if d.delta() != 0 {
+			deltas = append(deltas, *d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].delta() != deltas[j].delta() {
+			return deltas[i].delta() > deltas[j].delta()
+		}
+		return deltas[i].Package < deltas[j].Package
+	})
+	return deltas
+}
+
+// writeComparison prints the changed packages and returns how many
+// regressions (growth above threshold bytes) it found. The total size
+// counts as a regression too. A threshold of 0 disables the check.
+func writeComparison(w io.Writer, base, r *Report, match string, top int, threshold int64) int {
+	var regressions int
+	regressed := func(delta int64) string {
+		if threshold > 0 && delta > threshold {
+			regressions++
+			return "REGRESSION"
+		}
+		return ""
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "OLD\tNEW\tDELTA\t PACKAGE\t\n")
+	var shown int
+	for _, d := range compare(base, r) {
+		if match != "" && !strings.Contains(d.Package, match) {
+			continue
+		}
+		flag := regressed(d.delta())
+		// Regressions are always listed, whatever the limit
+		if top > 0 && shown >= top && flag == "" {
+			continue
+		}
+		shown++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t %s\t %s\n", formatBytes(d.Old), formatBytes(d.New), formatDelta(d.delta()), d.Package, flag)
+	}
+	total := r.Total - base.Total
+	fmt.Fprintf(tw, "%s\t%s\t%s\t %s\t %s\n", formatBytes(base.Total), formatBytes(r.Total), formatDelta(total), "(total)", regressed(total))
+	tw.Flush()
+
+	return regressions
+}
+
+func percent(size, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(size) * 100 / float64(total)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 || n <= -1<<20:
+		return fmt.Sprintf("%.2fM", float64(n)/(1<<20))
+	case n >= 1<<10 || n <= -1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+func formatDelta(n int64) string {
+	if n > 0 {
+		return "+" + formatBytes(n)
+	}
+	return formatBytes(n)
+}
@@ -0,0 +1,278 @@
+// cmd/wasm-size/wasm.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WebAssembly section IDs used by the analyzer
+const (
+	sectionCustom   = 0
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionCode     = 10
+	sectionData     = 11
+)
+
+// function is one function body of a WebAssembly module
+type function struct {
+	name string
+	size int64 // body size in bytes, including its length prefix
+}
+
+// module is what the analyzer needs from a WebAssembly binary
+type module struct {
+	fileSize  int64
+	functions []function
+	dataSize  int64            // data section
+	sections  map[string]int64 // size of every other section by name
+}
+
+// parseModule reads the sections of a WebAssembly binary. Function names
+// come from the "name" custom section, which the Go linker writes unless
+// symbols are stripped (-ldflags -s).
+func parseModule(data []byte) (*module, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a WebAssembly binary")
+	}
+	if v := binary.LittleEndian.Uint32(data[4:8]); v != 1 {
+		return nil, fmt.Errorf("unsupported WebAssembly version %d", v)
+	}
+
+	m := &module{
+		fileSize: int64(len(data)),
+		sections: make(map[string]int64),
+	}
+
+	var imported int
+	var names map[int]string
+	r := bytes.NewReader(data[8:])
+
+	for r.Len() > 0 {
+		start := r.Len()
+		id, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(r.Len()) {
+			return nil, fmt.Errorf("section %d is truncated", id)
+		}
+		body := make([]byte, size)
+		io.ReadFull(r, body)
+		total := int64(start - r.Len())
+
+		switch id {
+		case sectionImport:
+			if imported, err = countFunctionImports(body); err != nil {
+				return nil, fmt.Errorf("import section: %v", err)
+			}
+			m.sections["import"] += total
+		case sectionCode:
+			if m.functions, err = readCode(body); err != nil {
+				return nil, fmt.Errorf("code section: %v", err)
+			}
+			// The section header and function count are not part of any
+			// function
+			for _, f := range m.functions {
+				total -= f.size
+			}
+			m.sections["code"] += total
+		case sectionData:
+			m.dataSize += total
+		case sectionCustom:
+			br := bytes.NewReader(body)
+			name, err := readName(br)
+			if err != nil {
+				return nil, fmt.Errorf("custom section: %v", err)
+			}
+			if name == "name" {
+				if names, err = readFunctionNames(body[len(body)-br.Len():]); err != nil {
+					return nil, fmt.Errorf("name section: %v", err)
+				}
+			}
+			m.sections["custom:"+name] += total
+		default:
+			m.sections[sectionName(id)] += total
+		}
+	}
+
+	// Function indices count imported functions first
+	for i := range m.functions {
+		if name, ok := names[imported+i]; ok {
+			m.functions[i].name = name
+		}
+	}
+	return m, nil
+}
+
+// countFunctionImports returns how many imports are functions
+func countFunctionImports(body []byte) (int, error) {
+	r := bytes.NewReader(body)
+	count, err := readUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var functions int
+	for i := uint64(0); i < count; i++ {
+		// module and field names
+		for j := 0; j < 2; j++ {
+			if _, err := readName(r); err != nil {
+				return 0, err
+			}
+		}
+		kind, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // function: type index
+			functions++
+			_, err = readUvarint(r)
+		case 0x01: // table: element type and limits
+			if _, err = r.ReadByte(); err == nil {
+				err = skipLimits(r)
+			}
+		case 0x02: // memory: limits
+			err = skipLimits(r)
+		case 0x03: // global: value type and mutability
+			_, err = r.Seek(2, io.SeekCurrent)
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return functions, nil
+}
+
+func skipLimits(r *bytes.Reader) error {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if _, err := readUvarint(r); err != nil {
+		return err
+	}
+	if flags&1 != 0 {
+		_, err = readUvarint(r)
+	}
+	return err
+}
+
+// readCode returns the size of every function body in the code section
+func readCode(body []byte) ([]function, error) {
+	r := bytes.NewReader(body)
+	count, err := readUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	functions := make([]function, 0, count)
+	for i := uint64(0); i < count; i++ {
+		start := r.Len()
+		size, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		functions = append(functions, function{size: int64(start - r.Len())})
+	}
+	return functions, nil
+}
+
+// readFunctionNames reads the function name map (subsection 1) of the
+// name section
+func readFunctionNames(body []byte) (map[int]string, error) {
+	names := make(map[int]string)
+	r := bytes.NewReader(body)
+
+	for r.Len() > 0 {
+		id, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if id != 1 {
+			if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		count, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < count; i++ {
+			index, err := readUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+			name, err := readName(r)
+			if err != nil {
+				return nil, err
+			}
+			names[int(index)] = name
+		}
+	}
+	return names, nil
+}
+
+func readName(r *bytes.Reader) (string, error) {
+	n, err := readUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(r.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	io.ReadFull(r, b)
+	return string(b), nil
+}
+
+func readUvarint(r *bytes.Reader) (uint64, error) {
+	v, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func sectionName(id byte) string {
+	switch id {
+	case 1:
+		return "type"
+	case 4:
+		return "table"
+	case 5:
+		return "memory"
+	case 6:
+		return "global"
+	case 7:
+		return "export"
+	case 8:
+		return "start"
+	case 9:
+		return "element"
+	case 12:
+		return "datacount"
+	default:
+		return fmt.Sprintf("section %d", id)
+	}
+}
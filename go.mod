@@ -3,6 +3,7 @@ module github.com/mmcnicol/go-app-component-library
 go 1.25.7
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/maxence-charriere/go-app/v10 v10.1.11
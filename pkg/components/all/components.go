@@ -0,0 +1,21 @@
+// Code generated by go generate; DO NOT EDIT.
+// pkg/components/all/components.go
+
+package all
+
+import (
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/built_in"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/button"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/icon"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/input_text"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/input_text_area"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/label"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/panel"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/phase_banner"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/progress"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/select_one"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/static_message"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/table"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/toggle_switch"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/tree"
+)
@@ -0,0 +1,13 @@
+// pkg/components/all/doc.go
+
+// Package all imports every component package so that their init()
+// functions register stylesheets and (in dev builds) stories. Entry points
+// import this package instead of listing the components by hand.
+//
+// The import list lives in components.go and is regenerated by scanning
+// pkg/components/*. Generation fails if a component directory has no
+// stories, so a new component cannot silently go missing from the storybook;
+// `make check-generate` fails when components.go is out of date.
+package all
+
+//go:generate go run gen.go
@@ -0,0 +1,74 @@
+//go:build ignore
+// pkg/components/all/gen.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const modulePath = "github.com/mmcnicol/go-app-component-library/pkg/components/"
+
+// excluded lists component directories that are deliberately left out of
+// the manifest
+var excluded = map[string]bool{
+	"hello": true, // example component, kept out of the storybook
+}
+
+func main() {
+	entries, err := os.ReadDir("..")
+	if err != nil {
+		log.Fatalf("failed to read components directory: %v", err)
+	}
+
+	var packages, missing []string
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "all" || excluded[entry.Name()] {
+			continue
+		}
+
+		dir := filepath.Join("..", entry.Name())
+		sources, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		if len(sources) == 0 {
+			continue
+		}
+
+		stories, _ := filepath.Glob(filepath.Join(dir, "*_stories.go"))
+		if len(stories) == 0 {
+			missing = append(missing, entry.Name())
+			continue
+		}
+		packages = append(packages, entry.Name())
+	}
+
+	if len(missing) > 0 {
+		log.Fatalf("component directories without stories: %s", strings.Join(missing, ", "))
+	}
+
+	sort.Strings(packages)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by go generate; DO NOT EDIT.\n")
+	buf.WriteString("// pkg/components/all/components.go\n\n")
+	buf.WriteString("package all\n\n")
+	buf.WriteString("import (\n")
+	for _, pkg := range packages {
+		fmt.Fprintf(&buf, "\t_ %q\n", modulePath+pkg)
+	}
+	buf.WriteString(")\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format generated source: %v", err)
+	}
+
+	if err := os.WriteFile("components.go", src, 0644); err != nil {
+		log.Fatalf("failed to write components.go: %v", err)
+	}
+}
@@ -1,7 +1,14 @@
 // pkg/components/button/button.go
 package button
 
-import "github.com/maxence-charriere/go-app/v10/pkg/app"
+import (
+    "github.com/maxence-charriere/go-app/v10/pkg/app"
+    "github.com/mmcnicol/go-app-component-library/pkg/styles"
+)
+
+func init() {
+    styles.Register("button", "button.css")
+}
 
 type ButtonLook string
 
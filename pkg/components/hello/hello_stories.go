@@ -9,12 +9,12 @@ import (
 
 // Use init() to auto-register when this package is imported
 func init() {
-	storybook.Register("Hello", "Default", func() app.UI {
+	storybook.Register("Hello", "Default", nil, func(controls map[string]*storybook.Control) app.UI {
 		return &Hello{}
 	})
 
     // You can easily create variants
-	storybook.Register("Hello", "In Container", func() app.UI {
+	storybook.Register("Hello", "In Container", nil, func(controls map[string]*storybook.Control) app.UI {
 		return app.Div().
 			Style("background", "#f0f0f0").
 			Style("padding", "20px").
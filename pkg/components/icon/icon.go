@@ -4,8 +4,13 @@ package icon
 import (
 	"fmt"
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("icon", "icon.css")
+}
+
 type Icon struct {
 	app.Compo
 }
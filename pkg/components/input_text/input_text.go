@@ -3,8 +3,13 @@ package input_text
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("input_text", "input_text.css")
+}
+
 // InputText indicates service status
 type InputText struct {
 	app.Compo
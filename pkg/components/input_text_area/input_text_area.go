@@ -3,8 +3,13 @@ package input_text_area
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("input_text_area", "input_text_area.css")
+}
+
 type InputTextArea struct {
 	app.Compo
 	Value       string
@@ -1,7 +1,14 @@
 // pkg/components/label/label.go
 package label
 
-import "github.com/maxence-charriere/go-app/v10/pkg/app"
+import (
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
+)
+
+func init() {
+	styles.Register("label", "label.css")
+}
 
 type Label struct {
 	app.Compo
@@ -3,8 +3,13 @@ package phase_banner
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("phase_banner", "phase_banner.css")
+}
+
 // PhaseBanner indicates service status
 type PhaseBanner struct {
 	app.Compo
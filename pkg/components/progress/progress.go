@@ -1,7 +1,14 @@
 // pkg/components/progress/progress.go
 package progress
 
-import "github.com/maxence-charriere/go-app/v10/pkg/app"
+import (
+    "github.com/maxence-charriere/go-app/v10/pkg/app"
+    "github.com/mmcnicol/go-app-component-library/pkg/styles"
+)
+
+func init() {
+    styles.Register("progress", "progress.css")
+}
 
 type Progress struct {
     app.Compo
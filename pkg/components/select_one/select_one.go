@@ -3,8 +3,13 @@ package select_one
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("select_one", "select_one.css")
+}
+
 // SelectOne defines the UI component
 type SelectOne struct {
 	app.Compo
@@ -4,8 +4,13 @@ package static_message
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 	"github.com/mmcnicol/go-app-component-library/pkg/components/icon"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("static_message", "static_message.css")
+}
+
 type StaticMessage struct {
 	app.Compo
 	Severity string // info, warn, error, success
@@ -325,7 +325,7 @@ func (d *DataGrid) renderPageSizeSelector() app.UI {
     for _, size := range pageSizes {
         options = append(options, app.Option().
             Value(size).
-            Text(string(size)).
+            Text(fmt.Sprint(size)).
             Selected(size == d.props.PageSize))
     }
     
@@ -4,8 +4,16 @@ package table
 import (
     "fmt"
     "github.com/maxence-charriere/go-app/v10/pkg/app"
+    "github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+// The sortable table and the data grid build on the base table styles, so
+// their stylesheets must come after table.css. Registering them together
+// keeps that order independent of which file's init runs first.
+func init() {
+    styles.Register("table", "table.css", "sortable_table.css", "data_grid.css")
+}
+
 // TableProps defines properties for the base table component
 type TableProps struct {
     ID           string
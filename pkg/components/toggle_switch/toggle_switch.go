@@ -3,8 +3,13 @@ package toggle_switch
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 )
 
+func init() {
+	styles.Register("toggle_switch", "toggle_switch.css")
+}
+
 // ToggleSwitch defines the UI component
 type ToggleSwitch struct {
 	app.Compo
@@ -4,9 +4,14 @@ package tree
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 	"github.com/mmcnicol/go-app-component-library/pkg/components/icon"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 	"github.com/mmcnicol/go-app-component-library/pkg/theme"
 )
 
+func init() {
+	styles.Register("tree", "tree.css")
+}
+
 type TreeNode struct {
 	Label    string
 	Expanded bool
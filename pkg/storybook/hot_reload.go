@@ -0,0 +1,125 @@
+//go:build dev
+// pkg/storybook/hot_reload.go
+package storybook
+
+import (
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// devStateKey is the session storage key the Shell state is kept under
+// while the dev server reloads the page
+const devStateKey = "storybook-dev-state"
+
+// devHooksName is the window property the dev server's live-reload script
+// looks for. Outside the dev server nothing calls the hooks; builds without
+// the dev tag do not install them (see hot_reload_stub.go).
+const devHooksName = "__storybookDevHooks"
+
+// devState is the part of the Shell state that survives a hot reload
+type devState struct {
+	ActiveComponent string                    `json:"active_component"`
+	ActiveStory     string                    `json:"active_story"`
+	SearchQuery     string                    `json:"search_query"`
+	ShowControls    bool                      `json:"show_controls"`
+	SyncStories     bool                      `json:"sync_stories"`
+	Controls        map[string]map[string]any `json:"controls"` // "component/story" -> control key -> value
+	ScrollX         float64                   `json:"scroll_x"`
+	ScrollY         float64                   `json:"scroll_y"`
+	MainScrollTop   float64                   `json:"main_scroll_top"`
+}
+
+// installDevHooks exposes hooks to the live-reload script: saveState is
+// called right before reloading the page and selectStory when another
+// browser selects a story (see sync.go)
+func (s *Shell) installDevHooks(ctx app.Context) {
+	save := app.FuncOf(func(this app.Value, args []app.Value) any {
+		s.saveDevState(ctx)
+		return nil
+	})
+
+	// selectStory receives story selections made in other browsers
+	selectStory := app.FuncOf(func(this app.Value, args []app.Value) any {
+		if len(args) > 0 {
+			s.onRemoteSelect(ctx, args[0])
+		}
+		return nil
+	})
+
+	hooks := app.Window().Get("Object").New()
+	hooks.Set("saveState", save)
+	hooks.Set("selectStory", selectStory)
+	app.Window().Set(devHooksName, hooks)
+}
+
+// saveDevState writes the Shell state to session storage
+func (s *Shell) saveDevState(ctx app.Context) {
+	state := devState{
+		ActiveComponent: s.activeComponent,
+		ActiveStory:     s.activeStory,
+		SearchQuery:     s.searchQuery,
+		ShowControls:    s.showControls,
+		SyncStories:     s.syncStories,
+		Controls:        make(map[string]map[string]any),
+		ScrollX:         app.Window().Get("scrollX").Float(),
+		ScrollY:         app.Window().Get("scrollY").Float(),
+	}
+
+	if main := mainElement(); main.Truthy() {
+		state.MainScrollTop = main.Get("scrollTop").Float()
+	}
+
+	for _, comp := range GetRegistry() {
+		for _, story := range comp.Stories {
+			values := make(map[string]any, len(story.Controls))
+			for key, ctrl := range story.Controls {
+				values[key] = ctrl.Value
+			}
+			if len(values) > 0 {
+				state.Controls[comp.Name+"/"+story.Name] = values
+			}
+		}
+	}
+
+	if err := ctx.SessionStorage().Set(devStateKey, state); err != nil {
+		app.Log("saving dev state failed:", err)
+	}
+}
+
+// restoreDevState applies the state saved before a hot reload, if any.
+// The saved state is consumed so that a manual reload starts fresh.
+func (s *Shell) restoreDevState(ctx app.Context) {
+	var state devState
+	if err := ctx.SessionStorage().Get(devStateKey, &state); err != nil || state.Controls == nil {
+		return
+	}
+	ctx.SessionStorage().Del(devStateKey)
+
+	s.activeComponent = state.ActiveComponent
+	s.activeStory = state.ActiveStory
+	s.searchQuery = state.SearchQuery
+	s.showControls = state.ShowControls
+	s.syncStories = state.SyncStories
+
+	for _, comp := range GetRegistry() {
+		for _, story := range comp.Stories {
+			values := state.Controls[comp.Name+"/"+story.Name]
+			for key, ctrl := range story.Controls {
+				if v, ok := values[key]; ok {
+					ctrl.Value = controlValue(ctrl, v)
+				}
+			}
+		}
+	}
+
+	// Scroll once the restored story has been rendered
+	ctx.Defer(func(ctx app.Context) {
+		app.Window().Call("scrollTo", state.ScrollX, state.ScrollY)
+		if main := mainElement(); main.Truthy() {
+			main.Set("scrollTop", state.MainScrollTop)
+		}
+	})
+}
+
+func mainElement() app.Value {
+	return app.Window().Get("document").Call("querySelector", ".canvas-content")
+}
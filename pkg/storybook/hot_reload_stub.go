@@ -0,0 +1,14 @@
+//go:build !dev
+// pkg/storybook/hot_reload_stub.go
+package storybook
+
+import (
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// Without the dev tag the Shell has no hot reload state to restore and no
+// hooks for the live-reload script.
+
+func (s *Shell) restoreDevState(ctx app.Context) {}
+
+func (s *Shell) installDevHooks(ctx app.Context) {}
@@ -0,0 +1,128 @@
+// pkg/storybook/sync.go
+package storybook
+
+import (
+	"encoding/json"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// storySelection is exchanged between synced Shells through the dev
+// server: whichever browser selects a story or changes a control sends it,
+// and every other Shell with sync enabled follows.
+type storySelection struct {
+	Component string         `json:"component"`
+	Story     string         `json:"story"`
+	Controls  map[string]any `json:"controls,omitempty"`
+}
+
+// liveReload returns the dev server's live reload client, which is
+// undefined when the storybook is not served by the dev server
+func liveReload() app.Value {
+	if !app.IsClient {
+		return app.Undefined()
+	}
+	return app.Window().Get("liveReload")
+}
+
+// canSync reports whether story sync is available
+func canSync() bool {
+	lr := liveReload()
+	return lr.Truthy() && lr.Get("send").Truthy()
+}
+
+func (s *Shell) onToggleSync(ctx app.Context, e app.Event) {
+	s.syncStories = !s.syncStories
+	s.shouldRender = true
+
+	// Bring the other browsers to this one's story right away
+	if s.syncStories {
+		s.publishStory()
+	}
+}
+
+// publishStory sends the active story and its control values to the
+// other synced Shells
+func (s *Shell) publishStory() {
+	if !s.syncStories || s.activeComponent == "" || !canSync() {
+		return
+	}
+
+	selection := storySelection{
+		Component: s.activeComponent,
+		Story:     s.activeStory,
+		Controls:  make(map[string]any),
+	}
+	if story := s.getActiveStory(); story != nil {
+		for key, ctrl := range story.Controls {
+			selection.Controls[key] = ctrl.Value
+		}
+	}
+
+	data, err := json.Marshal(selection)
+	if err != nil {
+		app.Log("encoding story selection failed:", err)
+		return
+	}
+	payload := app.Window().Get("JSON").Call("parse", string(data))
+	liveReload().Call("send", "select-story", payload)
+}
+
+// onRemoteSelect is the dev hook the live reload client calls with a
+// story selection made in another browser or on the dashboard. It runs
+// outside the UI goroutine, so the state change is dispatched.
+func (s *Shell) onRemoteSelect(ctx app.Context, payload app.Value) {
+	var selection storySelection
+	raw := app.Window().Get("JSON").Call("stringify", payload).String()
+	if err := json.Unmarshal([]byte(raw), &selection); err != nil {
+		app.Log("decoding story selection failed:", err)
+		return
+	}
+
+	ctx.Dispatch(func(ctx app.Context) {
+		if !s.syncStories || selection.Component == "" {
+			return
+		}
+
+		// Unlike selectStory, the selection is not published back
+		s.applyControls(selection)
+		s.shouldRender = true
+		s.navigateToStory(ctx, selection.Component, selection.Story)
+	})
+}
+
+// applyControls sets the control values of the selected story
+func (s *Shell) applyControls(selection storySelection) {
+	for _, comp := range GetRegistry() {
+		if comp.Name != selection.Component {
+			continue
+		}
+		for _, story := range comp.Stories {
+			if story.Name != selection.Story {
+				continue
+			}
+			for key, ctrl := range story.Controls {
+				if v, ok := selection.Controls[key]; ok {
+					ctrl.Value = controlValue(ctrl, v)
+				}
+			}
+		}
+	}
+}
+
+// controlValue converts a JSON decoded value back to the type the control
+// expects (JSON numbers decode as float64)
+func controlValue(ctrl *Control, v any) any {
+	switch ctrl.Type {
+	case ControlNumber, ControlRange:
+		if f, ok := v.(float64); ok {
+			return int(f)
+		}
+	case ControlBool:
+		if b, ok := v.(bool); ok {
+			return b
+		}
+		return ctrl.Value
+	}
+	return v
+}
@@ -3,10 +3,17 @@ package storybook
 
 import (
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
 	"strconv"
 	"strings"
 )
 
+func init() {
+	// The storybook layout loads before the component stylesheets, which
+	// may override it
+	styles.RegisterBase("main.css")
+}
+
 type Shell struct {
 	app.Compo
 
@@ -15,6 +22,7 @@ type Shell struct {
 	searchQuery     string
 	shouldRender    bool
 	showControls    bool
+	syncStories     bool // follow story selections from other browsers
 	IsDark          bool
 	Notifications   *NotificationComponent
 }
@@ -22,10 +30,24 @@ type Shell struct {
 func (s *Shell) OnMount(ctx app.Context) {
     ctx.LocalStorage().Get("storybook-theme-dark", &s.IsDark)
     s.Notifications = &NotificationComponent{} // Add this line
+
+    // Keep the selected story and controls across dev server reloads
+    s.restoreDevState(ctx)
+    s.installDevHooks(ctx)
+
     ctx.Update()
     s.shouldRender = true
 }
 
+// OnNav restores the selected story from the URL so that deep links
+// (e.g. /?component=Form&story=Button) open the right story
+func (s *Shell) OnNav(ctx app.Context) {
+	q := ctx.Page().URL().Query()
+	s.activeComponent = q.Get("component")
+	s.activeStory = q.Get("story")
+	s.shouldRender = true
+}
+
 func (s *Shell) Render() app.UI {
 	if app.IsClient {
 		app.Log("Shell Render()")
@@ -128,6 +150,17 @@ func (s *Shell) Render() app.UI {
                         s.showControls = !s.showControls
                         s.shouldRender = true
                     }),
+                app.If(canSync(), func() app.UI {
+                    syncClass := "toggle-sync-btn"
+                    if s.syncStories {
+                        syncClass += " active"
+                    }
+                    return app.Button().
+                        Class(syncClass).
+                        Title("Follow story selections made in other browsers and share this one's").
+                        Text("⇄ Sync").
+                        OnClick(s.onToggleSync)
+                }),
             ),
             
 			// Add notifications container here
@@ -137,10 +170,7 @@ func (s *Shell) Render() app.UI {
 
             app.Div().Class("canvas-content").Body(
                 app.If(s.activeComponent != "", func() app.UI {
-                    story := s.getActiveStory()
-                    return app.Div().Class("story-container").Body(
-                        story.Render(story.Controls),
-                    )
+                    return s.renderActiveStory()
                 }).Else(func() app.UI {
                     return app.Div().Class("empty-state").Text("Select a story")
                 }),
@@ -161,6 +191,14 @@ func (s *Shell) selectStory(ctx app.Context, compName, storyName string) {
 	if app.IsClient {
 		app.Log("Shell selectStory()")
 	}
+	s.navigateToStory(ctx, compName, storyName)
+	s.publishStory()
+	//s.shouldRender = true
+	//ctx.Update()
+}
+
+// navigateToStory makes a story active and records it in the URL
+func (s *Shell) navigateToStory(ctx app.Context, compName, storyName string) {
 	s.activeComponent = compName
 	s.activeStory = storyName
 
@@ -171,8 +209,6 @@ func (s *Shell) selectStory(ctx app.Context, compName, storyName string) {
 	u.RawQuery = q.Encode()
 
 	ctx.Navigate(u.String())
-	//s.shouldRender = true
-	//ctx.Update()
 }
 
 func (s *Shell) getActiveStory() *Story {
@@ -305,6 +341,7 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
                 ctrl.Value = ctx.JSSrc().Get("value").String()
                 s.shouldRender = true
                 ctx.Update()
+                s.publishStory()
             }).
             Body(options...)
 	case ControlRange:
@@ -318,6 +355,7 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
 				ctrl.Value = i
 				s.shouldRender = true
 				ctx.Update()
+				s.publishStory()
 			})
 
 	case ControlColor:
@@ -329,6 +367,7 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
 				ctrl.Value = ctx.JSSrc().Get("value").String()
 				s.shouldRender = true
 				ctx.Update()
+				s.publishStory()
 			})
 
     case ControlBool:
@@ -339,6 +378,7 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
 				ctrl.Value = ctx.JSSrc().Get("checked").Bool()
 				s.shouldRender = true
 				ctx.Update()
+				s.publishStory()
 			})
 
 	case ControlSelect:
@@ -361,6 +401,7 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
                 ctrl.Value = ctx.JSSrc().Get("value").String()
                 s.shouldRender = true
                 ctx.Update()
+                s.publishStory()
             }).
             Body(options...)
 
@@ -382,6 +423,8 @@ func (s *Shell) renderControlInput(key string, ctrl *Control) app.UI {
                 
                 s.shouldRender = true
                 ctx.Update() // Essential to notify go-app to diff the DOM
+                
+                s.publishStory()
             })
 
 	default:
@@ -0,0 +1,46 @@
+// pkg/styles/bundle_test.go
+package styles_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mmcnicol/go-app-component-library/pkg/styles"
+
+	// Register the storybook's and every component's stylesheets, as the
+	// storybook server does
+	_ "github.com/mmcnicol/go-app-component-library/pkg/components/all"
+	_ "github.com/mmcnicol/go-app-component-library/pkg/storybook"
+)
+
+// TestBundleOrder pins the cascade order of the storybook bundle: design
+// tokens, then the storybook layout, then the components, with the table
+// stylesheets that build on table.css after it
+func TestBundleOrder(t *testing.T) {
+	bundle, err := styles.Build("../../web/style")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []string{
+		"variables.css",
+		"main.css",
+		"button.css",
+		"icon.css",
+		"input_text.css",
+		"input_text_area.css",
+		"label.css",
+		"phase_banner.css",
+		"progress.css",
+		"select_one.css",
+		"static_message.css",
+		"table.css",
+		"sortable_table.css",
+		"data_grid.css",
+		"toggle_switch.css",
+		"tree.css",
+	}
+	if !reflect.DeepEqual(bundle.Files, want) {
+		t.Errorf("bundle order\n got: %v\nwant: %v", bundle.Files, want)
+	}
+}
@@ -0,0 +1,69 @@
+// pkg/styles/registry.go
+package styles
+
+import "sync"
+
+// Base lists the stylesheets every bundle starts with, in order.
+// The design tokens must load before any component stylesheet.
+// RegisterBase adds to it.
+var Base = []string{"variables.css"}
+
+type entry struct {
+	Owner string
+	Files []string
+}
+
+var (
+	mu       sync.RWMutex
+	registry []entry
+)
+
+// Register declares the stylesheet(s) a component needs. Paths are relative
+// to the style directory (e.g. "button.css"). Components call this from an
+// init() in their non-story source so production builds pick it up too.
+func Register(owner string, files ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := range registry {
+		if registry[i].Owner == owner {
+			registry[i].Files = append(registry[i].Files, files...)
+			return
+		}
+	}
+	registry = append(registry, entry{Owner: owner, Files: files})
+}
+
+// RegisterBase declares stylesheets that load after Base and before any
+// component stylesheet, e.g. an application's layout
+func RegisterBase(files ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	Base = append(Base, files...)
+}
+
+// Stylesheets returns the base stylesheets followed by every registered
+// component stylesheet, in registration order and without duplicates.
+func Stylesheets() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	for _, f := range Base {
+		add(f)
+	}
+	for _, e := range registry {
+		for _, f := range e.Files {
+			add(f)
+		}
+	}
+	return files
+}
@@ -0,0 +1,267 @@
+// pkg/wasmserve/wasmserve.go
+
+// Package wasmserve serves WebAssembly binaries compressed. A brotli (.br)
+// or gzip (.gz) file written next to the binary at build time (see
+// Precompress) is preferred; without one the binary is compressed on the
+// fly and the result kept in memory until the binary changes.
+//
+// Binaries are always served as application/wasm, which browsers require
+// for streaming compilation.
+package wasmserve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// maxCached is how many binaries are kept in memory. The dev server writes
+// a new file per build, so older entries are evicted.
+const maxCached = 4
+
+// encodings in order of preference, with the extension of their
+// pre-compressed files
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Server serves WebAssembly binaries from disk
+type Server struct {
+	// Production enables caching: responses carry an ETag and browsers
+	// revalidate with If-None-Match. Otherwise responses must not be
+	// cached at all, which is what a development server wants.
+	Production bool
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// entry is a binary loaded into memory with its encoded variants
+type entry struct {
+	modTime time.Time
+	size    int64
+	hash    string
+	content []byte
+	encoded map[string][]byte // encoding -> content
+	used    time.Time
+}
+
+// New returns a Server; production enables ETag based caching
+func New(production bool) *Server {
+	return &Server{Production: production}
+}
+
+// ServeFile serves the binary at path, compressed when the client accepts
+// brotli or gzip
+func (s *Server) ServeFile(w http.ResponseWriter, r *http.Request, path string) {
+	e, err := s.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "failed to read WebAssembly binary", http.StatusInternalServerError)
+		return
+	}
+
+	content, encoding := e.content, ""
+	for _, enc := range encodings {
+		if !accepts(r, enc.name) {
+			continue
+		}
+		if data, err := s.encode(path, e, enc.name, enc.ext); err == nil {
+			content, encoding = data, enc.name
+			break
+		}
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/wasm")
+	h.Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+	}
+
+	var modTime time.Time
+	if s.Production {
+		// The variants differ byte for byte, so each gets its own tag
+		etag := e.hash
+		if encoding != "" {
+			etag += "-" + encoding
+		}
+		h.Set("ETag", `"`+etag+`"`)
+		h.Set("Cache-Control", "no-cache")
+		modTime = e.modTime
+	} else {
+		h.Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		h.Set("Pragma", "no-cache")
+		h.Set("Expires", "0")
+	}
+
+	// ServeContent answers conditional and range requests
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(content))
+}
+
+// load returns the binary at path, reading it again when it changed
+func (s *Server) load(path string) (*entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	e, ok := s.entries[path]
+	if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		e.used = time.Now()
+		s.mu.Unlock()
+		return e, nil
+	}
+	s.mu.Unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	e = &entry{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    hex.EncodeToString(sum[:])[:16],
+		content: content,
+		encoded: make(map[string][]byte),
+		used:    time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*entry)
+	}
+	s.entries[path] = e
+	s.evict()
+	return e, nil
+}
+
+// evict drops the least recently used binaries beyond maxCached
+func (s *Server) evict() {
+	for len(s.entries) > maxCached {
+		var oldest string
+		for path, e := range s.entries {
+			if oldest == "" || e.used.Before(s.entries[oldest].used) {
+				oldest = path
+			}
+		}
+		delete(s.entries, oldest)
+	}
+}
+
+// encode returns the binary in the given encoding, from its pre-compressed
+// file when that is at least as new as the binary
+func (s *Server) encode(path string, e *entry, encoding, ext string) ([]byte, error) {
+	s.mu.Lock()
+	data, ok := e.encoded[encoding]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	if info, err := os.Stat(path + ext); err == nil && !info.ModTime().Before(e.modTime) {
+		data, err = os.ReadFile(path + ext)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Compressing on the fly happens while a browser waits, so it
+		// trades some size for speed
+		if data, err = compress(e.content, encoding, false); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	e.encoded[encoding] = data
+	s.mu.Unlock()
+	return data, nil
+}
+
+// Precompress writes brotli (.br) and gzip (.gz) versions of the file at
+// path next to it, at the best compression level
+func Precompress(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, enc := range encodings {
+		data, err := compress(content, enc.name, true)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path+enc.ext, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compress(content []byte, encoding string, best bool) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch encoding {
+	case "br":
+		quality := 5
+		if best {
+			quality = brotli.BestCompression
+		}
+		w = brotli.NewWriterLevel(&buf, quality)
+	default:
+		level := gzip.DefaultCompression
+		if best {
+			level = gzip.BestCompression
+		}
+		gz, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		w = gz
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// accepts reports whether the request's Accept-Encoding allows encoding
+func accepts(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}